@@ -0,0 +1,17 @@
+resource "coder_agent" "dev" {
+  os   = "linux"
+  arch = "amd64"
+}
+
+data "coder_agent_init" "dev" {
+  init_script = coder_agent.dev.init_script
+  token       = coder_agent.dev.token
+  os          = coder_agent.dev.os
+  user        = "coder"
+}
+
+resource "aws_instance" "dev" {
+  ami           = "ami-0123456789abcdef0"
+  instance_type = "t3.micro"
+  user_data     = data.coder_agent_init.dev.user_data
+}
@@ -22,5 +22,23 @@ resource "coder_agent" "main" {
       enabled   = true
       threshold = 100
     }
+    cpu {
+      enabled   = true
+      threshold = 90
+      window    = 300
+      duration  = 600
+      severity  = "critical"
+    }
+    inodes {
+      path      = "/volume1"
+      enabled   = true
+      threshold = 90
+    }
+    network {
+      interface = "eth0"
+      enabled   = true
+      threshold = 1000
+      duration  = 120
+    }
   }
 }
\ No newline at end of file
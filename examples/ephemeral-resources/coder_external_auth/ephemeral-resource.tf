@@ -0,0 +1,12 @@
+# Declare the requirement so users are prompted to authenticate.
+data "coder_external_auth" "github" {
+  id = "github"
+}
+
+ephemeral "coder_external_auth" "github" {
+  id = data.coder_external_auth.github.id
+}
+
+provider "github" {
+  token = ephemeral.coder_external_auth.github.access_token
+}
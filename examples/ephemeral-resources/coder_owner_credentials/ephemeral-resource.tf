@@ -0,0 +1,8 @@
+data "coder_workspace" "me" {}
+
+ephemeral "coder_owner_credentials" "me" {}
+
+provider "coderd" {
+  url   = data.coder_workspace.me.access_url
+  token = ephemeral.coder_owner_credentials.me.session_token
+}
@@ -0,0 +1,10 @@
+ephemeral "coder_secret" "github_token" {
+  env          = "GITHUB_TOKEN"
+  help_message = "Add a GitHub personal access token as a user secret."
+}
+
+# Ephemeral values can be used in provider configurations without being
+# written to the plan or state.
+provider "github" {
+  token = ephemeral.coder_secret.github_token.value
+}
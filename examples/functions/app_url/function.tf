@@ -0,0 +1,9 @@
+resource "coder_app" "code-server" {
+  agent_id = coder_agent.main.id
+  slug     = "code-server"
+  url      = "http://localhost:13337"
+}
+
+output "code_server_url" {
+  value = provider::coder::app_url("main", coder_app.code-server.slug)
+}
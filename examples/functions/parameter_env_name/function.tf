@@ -0,0 +1,5 @@
+# Prints "CODER_PARAMETER_<sha256 of the name>", the environment variable
+# Coder uses to pass the parameter value to the provider.
+output "region_env" {
+  value = provider::coder::parameter_env_name("region")
+}
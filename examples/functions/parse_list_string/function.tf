@@ -0,0 +1,13 @@
+data "coder_parameter" "repos" {
+  name    = "repos"
+  type    = "list(string)"
+  default = jsonencode(["coder/coder", "coder/terraform-provider-coder"])
+}
+
+resource "coder_script" "clone" {
+  for_each     = toset(provider::coder::parse_list_string(data.coder_parameter.repos.value))
+  agent_id     = coder_agent.dev.id
+  display_name = "Clone ${each.value}"
+  run_on_start = true
+  script       = "git clone https://github.com/${each.value}"
+}
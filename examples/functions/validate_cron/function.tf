@@ -0,0 +1,9 @@
+variable "backup_schedule" {
+  type    = string
+  default = "0 0 22 * * *"
+
+  validation {
+    condition     = provider::coder::validate_cron(var.backup_schedule)
+    error_message = "backup_schedule must be a 6-field cron expression."
+  }
+}
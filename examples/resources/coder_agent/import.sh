@@ -0,0 +1,7 @@
+# The import ID is the agent ID followed by its os and arch, and optionally
+# its auth, api_key_scope and comma-separated api_key_scopes if they are not
+# the defaults. The token is not imported, it is generated on every build like
+# for any other agent.
+terraform import coder_agent.dev 5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:linux:amd64
+terraform import coder_agent.dev 5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:linux:amd64:aws-instance-identity:no_user_data
+terraform import coder_agent.dev 5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:linux:amd64:token:all:metadata_read,metadata_write
@@ -30,6 +30,15 @@ resource "coder_agent" "dev" {
     order        = 1
   }
 
+  readiness_probe {
+    http {
+      url = "http://localhost:8080/healthz"
+    }
+    initial_delay = 5
+    interval      = 10
+    timeout       = 2
+  }
+
   order = 1
 }
 
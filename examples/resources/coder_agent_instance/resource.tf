@@ -12,3 +12,23 @@ resource "coder_agent_instance" "dev" {
   agent_id    = coder_agent.dev.id
   instance_id = google_compute_instance.dev.instance_id
 }
+
+resource "coder_agent" "pod" {
+  os   = "linux"
+  arch = "amd64"
+  auth = "kubernetes-service-account"
+  workload_identity {
+    audience = "coder"
+  }
+}
+
+resource "kubernetes_pod" "dev" {
+  metadata {
+    name = "coder-dev"
+  }
+}
+
+resource "coder_agent_instance" "pod" {
+  agent_id = coder_agent.pod.id
+  pod_uid  = kubernetes_pod.dev.metadata[0].uid
+}
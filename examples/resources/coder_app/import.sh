@@ -0,0 +1,2 @@
+# The import ID is the app ID followed by its agent_id and slug.
+terraform import coder_app.code-server 0c9f4b1a-6d2e-4f7a-9b3c-1e8d5a2f6c4b:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:code-server
@@ -34,3 +34,32 @@ resource "coder_app" "vim" {
   icon         = "${data.coder_workspace.me.access_url}/icon/vim.svg"
   command      = "vim"
 }
+
+resource "coder_app" "grpc" {
+  agent_id     = coder_agent.dev.id
+  slug         = "grpc"
+  display_name = "gRPC Server"
+  url          = "http://localhost:50051"
+  healthcheck {
+    type              = "tcp"
+    address           = "localhost:50051"
+    interval          = 10
+    timeout           = 2
+    initial_delay     = 5
+    threshold         = 3
+    success_threshold = 2
+  }
+}
+
+resource "coder_app" "vscode-desktop" {
+  agent_id     = coder_agent.dev.id
+  slug         = "vscode-desktop"
+  display_name = "VS Code Desktop"
+  icon         = "${data.coder_workspace.me.access_url}/icon/code.svg"
+  deeplink {
+    scheme   = "vscode"
+    template = "coder.coder-remote/open?owner={owner}&workspace={workspace}&agent={agent}&folder={folder}&url={access_url}&token={token}"
+    # The VS Code extension authenticates with the session token in the URI.
+    allow_token = true
+  }
+}
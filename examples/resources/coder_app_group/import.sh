@@ -0,0 +1,2 @@
+# The import ID is the agent_id and name of the group.
+terraform import coder_app_group.ides 5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f/ides
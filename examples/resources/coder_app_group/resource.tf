@@ -0,0 +1,37 @@
+data "coder_workspace" "me" {}
+
+resource "coder_agent" "dev" {
+  os   = "linux"
+  arch = "amd64"
+  dir  = "/workspace"
+}
+
+resource "coder_app_group" "ides" {
+  agent_id     = coder_agent.dev.id
+  name         = "ides"
+  display_name = "IDEs"
+  icon         = "${data.coder_workspace.me.access_url}/icon/code.svg"
+  order        = 1
+}
+
+resource "coder_app_group" "monitoring" {
+  agent_id             = coder_agent.dev.id
+  name                 = "monitoring"
+  display_name         = "Monitoring"
+  order                = 2
+  collapsed_by_default = true
+}
+
+resource "coder_app" "code-server" {
+  agent_id = coder_agent.dev.id
+  slug     = "code-server"
+  url      = "http://localhost:13337"
+  group_id = coder_app_group.ides.id
+}
+
+resource "coder_app" "grafana" {
+  agent_id = coder_agent.dev.id
+  slug     = "grafana"
+  url      = "http://localhost:3000"
+  group_id = coder_app_group.monitoring.id
+}
@@ -0,0 +1,6 @@
+# The import ID is the Dev Container ID followed by its subagent_id, agent_id
+# and workspace_folder, and optionally its config_path.
+terraform import coder_devcontainer.coder 9d8c7b6a-5f4e-4d3c-2b1a-0f9e8d7c6b5a:1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:/workspaces/coder
+
+# Quote values that contain a colon, such as Windows paths.
+terraform import coder_devcontainer.coder '9d8c7b6a-5f4e-4d3c-2b1a-0f9e8d7c6b5a:1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:`C:\workspaces\coder`'
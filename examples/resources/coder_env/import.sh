@@ -0,0 +1,2 @@
+# The import ID is the environment variable ID followed by its agent_id and name.
+terraform import coder_env.welcome_message 3b5d7f9a-1c2e-4d6f-8a0b-2c4e6a8b0d1f:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:WELCOME_MESSAGE
@@ -0,0 +1,2 @@
+# The import ID is the external agent ID followed by its agent_id.
+terraform import coder_external_agent.dev 2c4e6a8b-0d1f-4b3d-9e5f-7a9c1e3b5d7f:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f
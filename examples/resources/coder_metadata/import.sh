@@ -0,0 +1,2 @@
+# The import ID is the metadata ID followed by its resource_id.
+terraform import coder_metadata.pod_info 6f5e4d3c-2b1a-4098-8f7e-6d5c4b3a2918:i-0123456789abcdef0
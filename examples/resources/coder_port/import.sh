@@ -0,0 +1,4 @@
+# The import ID is the port ID followed by its agent_id and port, and
+# optionally its protocol, share level and display name. Quote values that
+# contain a colon.
+terraform import coder_port.web 3b6f2c1d-9a4e-4c8b-8f2d-7e1a5c9b0d3e:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f:3000:http:organization:Web
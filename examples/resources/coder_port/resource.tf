@@ -0,0 +1,19 @@
+resource "coder_agent" "dev" {
+  os   = "linux"
+  arch = "amd64"
+  dir  = "/workspace"
+}
+
+resource "coder_port" "web" {
+  agent_id     = coder_agent.dev.id
+  port         = 3000
+  share        = "organization"
+  display_name = "Web"
+}
+
+resource "coder_port" "postgres" {
+  agent_id     = coder_agent.dev.id
+  port         = 5432
+  protocol     = "tcp"
+  display_name = "PostgreSQL"
+}
@@ -0,0 +1,2 @@
+# The import ID is the script ID followed by its agent_id.
+terraform import coder_script.dotfiles 7a1c3e5b-2d4f-4a6b-8c9d-0e1f2a3b4c5d:5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f
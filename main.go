@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"log"
+
 	// Embed timezone data for use in environments that may not have the
 	// timezone database available (e.g. scratch Docker images).
 	_ "time/tzdata"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
 
 	"github.com/coder/terraform-provider-coder/v2/provider"
 )
@@ -19,12 +23,25 @@ func main() {
 	debug := flag.Bool("debug", false, "Enable debug mode for the provider")
 	flag.Parse()
 
-	opts := &plugin.ServeOpts{
-		Debug:        *debug,
-		ProviderAddr: "registry.terraform.io/coder/coder",
-		ProviderFunc: provider.New,
+	// The SDKv2 provider serves the resources and data sources it has always
+	// served. The plugin-framework provider serves provider-defined functions,
+	// ephemeral resources, and the resources and data sources returned by
+	// frameworkProvider.Resources and frameworkProvider.DataSources.
+	server, err := provider.NewMuxServer(context.Background(), provider.EnvBuildContextSource)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var opts []tf6server.ServeOpt
+	if *debug {
+		opts = append(opts, tf6server.WithManagedDebug())
 	}
 
 	servePprof()
-	plugin.Serve(opts)
+	err = tf6server.Serve("registry.terraform.io/coder/coder", func() tfprotov6.ProviderServer {
+		return server
+	}, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
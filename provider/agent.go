@@ -5,8 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -20,62 +24,78 @@ import (
 	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
 
+var (
+	// agentOperatingSystems and agentArchitectures are the platforms an agent
+	// can run on. During a workspace build, coderd passes the init script for
+	// each platform in CODER_AGENT_SCRIPT_<os>_<arch>.
+	agentOperatingSystems = []string{"linux", "darwin", "windows", "freebsd"}
+	agentArchitectures    = []string{"amd64", "armv7", "arm64", "riscv64", "ppc64le"}
+)
+
 func agentResource() *schema.Resource {
-	return &schema.Resource{
-		SchemaVersion: 1,
+	resource := &schema.Resource{
+		SchemaVersion: 3,
 
 		Description: "Use this resource to associate an agent.",
 		CreateContext: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			agentID := uuid.NewString()
 			resourceData.SetId(agentID)
 
-			token := agentAuthToken(ctx, "")
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
+
+			token := agentAuthToken(ctx, bc, resourceData.Id())
 			err := resourceData.Set("token", token)
 			if err != nil {
 				return diag.FromErr(err)
 			}
 
-			if _, ok := resourceData.GetOk("display_apps"); !ok {
-				err = resourceData.Set("display_apps", []interface{}{
-					map[string]bool{
-						"vscode":                 true,
-						"vscode_insiders":        false,
-						"web_terminal":           true,
-						"ssh_helper":             true,
-						"port_forwarding_helper": true,
-					},
-				})
-				if err != nil {
-					return diag.FromErr(err)
-				}
+			if err := setDefaultAPIKeyScopes(resourceData); err != nil {
+				return diag.FromErr(err)
 			}
-				return updateInitScript(resourceData, i)
-			},
+
+			if err := setDefaultDisplayApps(resourceData); err != nil {
+				return diag.FromErr(err)
+			}
+			return updateInitScript(resourceData, i)
+		},
 
 		ReadWithoutTimeout: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
-			token := agentAuthToken(ctx, "")
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
+
+			token := agentAuthToken(ctx, bc, resourceData.Id())
 			err := resourceData.Set("token", token)
 			if err != nil {
 				return diag.FromErr(err)
 			}
 
-			if _, ok := resourceData.GetOk("display_apps"); !ok {
-				err = resourceData.Set("display_apps", []interface{}{
-					map[string]bool{
-						"vscode":                 true,
-						"vscode_insiders":        false,
-						"web_terminal":           true,
-						"ssh_helper":             true,
-						"port_forwarding_helper": true,
-					},
-				})
-				if err != nil {
-					return diag.FromErr(err)
-				}
+			if err := setDefaultAPIKeyScopes(resourceData); err != nil {
+				return diag.FromErr(err)
 			}
 
-				return updateInitScript(resourceData, i)
-			},
+			if err := setDefaultDisplayApps(resourceData); err != nil {
+				return diag.FromErr(err)
+			}
+
+			return updateInitScript(resourceData, i)
+		},
+
+		// Only os, arch, auth and api_key_scope force a new agent, since they
+		// determine how the agent authenticates. Everything else is updated in
+		// place without changing the agent ID or token, so that resources which
+		// embed them are not replaced. init_script is rendered again, and only
+		// changes with init_script_vars.
+		UpdateContext: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+			if err := setDefaultDisplayApps(resourceData); err != nil {
+				return diag.FromErr(err)
+			}
+			return updateInitScript(resourceData, i)
+		},
 
 		DeleteContext: func(ctx context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			return nil
@@ -86,69 +106,127 @@ func agentResource() *schema.Resource {
 				Optional:    true,
 				Default:     "all",
 				ForceNew:    true,
-				Description: "Controls what API routes the agent token can access. Options: `all` (full access) or `no_user_data` (blocks `/external-auth`, `/gitsshkey`, and `/gitauth` routes)",
+				Description: "Controls what API routes the agent token can access. Options: `all` (full access) or `no_user_data` (blocks `/external-auth`, `/gitsshkey`, and `/gitauth` routes). Each option is a preset of `api_key_scopes`.",
 				ValidateFunc: validation.StringInSlice([]string{
 					"all",
 					"no_user_data",
 				}, false),
 			},
+			"api_key_scopes": {
+				ForceNew: true,
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				MinItems: 1,
+				Description: "The route groups the agent token can access, for finer control than `api_key_scope`. " +
+					"Routes outside of these groups, which the agent needs to connect, are always accessible. " +
+					"Defaults to the scopes of the `api_key_scope` preset: `all` grants every scope, and `no_user_data` " +
+					"grants every scope except `external_auth` and `git_ssh_key`. Scopes: " + agentAPIKeyScopesDescription() + ".",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(agentAPIKeyScopeNames(), false),
+				},
+				ConflictsWith: []string{"api_key_scope"},
+			},
 			"init_script": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Run this script on startup of an instance to initialize the agent.",
+				Description: "Run this script on startup of an instance to initialize the agent. Use the `coder_agent_init` data source to render it as cloud-init or other user data.",
+			},
+			"init_script_vars": {
+				Type: schema.TypeMap,
+				Description: "Additional placeholders to substitute in `init_script`. Each `${NAME}` in the " +
+					"script Coder provides is replaced by the value of `NAME`, e.g. `HTTP_PROXY`, `HTTPS_PROXY`, " +
+					"`NO_PROXY`, `CA_BUNDLE` (the path to a PEM bundle trusted when connecting to Coder), " +
+					"`BINARY_URL` (a mirror to download the agent from) or `TOKEN_FILE` (the path the token " +
+					"is read from). Placeholders that are not set are left as-is for the shell to expand. " +
+					"`${ACCESS_URL}`, `${AUTH_TYPE}` and `${AGENT_ID}` are always substituted and cannot be set here.",
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				ValidateDiagFunc: validateInitScriptVars,
 			},
 			"arch": {
 				Type:         schema.TypeString,
 				ForceNew:     true,
 				Required:     true,
-				Description:  "The architecture the agent will run on. Must be one of: `\"amd64\"`, `\"armv7\"`, `\"arm64\"`.",
-				ValidateFunc: validation.StringInSlice([]string{"amd64", "armv7", "arm64"}, false),
+				Description:  "The architecture the agent will run on. Must be one of: `\"amd64\"`, `\"armv7\"`, `\"arm64\"`, `\"riscv64\"`, `\"ppc64le\"`.",
+				ValidateFunc: validation.StringInSlice(agentArchitectures, false),
 			},
 			"auth": {
 				Type:         schema.TypeString,
 				Default:      "token",
 				ForceNew:     true,
 				Optional:     true,
-				Description:  "The authentication type the agent will use. Must be one of: `\"token\"`, `\"google-instance-identity\"`, `\"aws-instance-identity\"`, `\"azure-instance-identity\"`.",
-				ValidateFunc: validation.StringInSlice([]string{"token", "google-instance-identity", "aws-instance-identity", "azure-instance-identity"}, false),
-			},
-				"dir": {
-					Type:        schema.TypeString,
-					ForceNew:    true,
-					Optional:    true,
-					Deprecated:  "dir has been deprecated and will be removed in a future release.",
-					Description: "The starting directory when a user creates a shell session. Defaults to `\"$HOME\"`." +
-						"\n\n~> **Warning:** This attribute is deprecated and will be removed in a future release. " +
-						"Setting `dir` to a value other than `$HOME` will break " +
-						"[Coder Desktop file sync](https://coder.com/docs/user-guides/desktop/desktop-connect-sync).",
-					ValidateFunc: helpers.WarnDirNotHome,
+				Description:  "The authentication type the agent will use. Must be one of: `\"token\"`, `\"google-instance-identity\"`, `\"aws-instance-identity\"`, `\"azure-instance-identity\"`, `\"kubernetes-service-account\"`, `\"oidc-workload-identity\"`. The workload identity types are configured with `workload_identity`.",
+				ValidateFunc: validation.StringInSlice([]string{"token", "google-instance-identity", "aws-instance-identity", "azure-instance-identity", "kubernetes-service-account", "oidc-workload-identity"}, false),
+			},
+			"workload_identity": {
+				ForceNew: true,
+				Type:     schema.TypeList,
+				Description: "Configures how the agent authenticates with the token of its workload, when `auth` is `\"kubernetes-service-account\"` " +
+					"or `\"oidc-workload-identity\"`. The agent exchanges the token for an agent token, and the workload is matched to the agent " +
+					"with a `coder_agent_instance` resource.",
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"issuer": {
+							Type: schema.TypeString,
+							Description: "The HTTPS URL of the issuer of the workload's token, which must serve OpenID Connect discovery. " +
+								"Required for `\"oidc-workload-identity\"`. For `\"kubernetes-service-account\"`, defaults to the issuer of the cluster Coder runs in.",
+							Optional:     true,
+							ValidateFunc: validation.IsURLWithHTTPS,
+						},
+						"audience": {
+							Type:        schema.TypeString,
+							Description: "The audience the workload's token must be issued for. Defaults to the Coder access URL.",
+							Optional:    true,
+						},
+						"token_file": {
+							Type: schema.TypeString,
+							Description: "The path the agent reads the workload's token from. Defaults to " +
+								"`/var/run/secrets/kubernetes.io/serviceaccount/token` for `\"kubernetes-service-account\"`. " +
+								"Required for `\"oidc-workload-identity\"`.",
+							Optional: true,
+						},
+					},
 				},
+			},
+			"dir": {
+				Type:       schema.TypeString,
+				Optional:   true,
+				Deprecated: "dir has been deprecated and will be removed in a future release.",
+				Description: "The starting directory when a user creates a shell session. Defaults to `\"$HOME\"`." +
+					"\n\n~> **Warning:** This attribute is deprecated and will be removed in a future release. " +
+					"Setting `dir` to a value other than `$HOME` will break " +
+					"[Coder Desktop file sync](https://coder.com/docs/user-guides/desktop/desktop-connect-sync).",
+				ValidateFunc: helpers.WarnDirNotHome,
+			},
 
 			"env": {
-				ForceNew:    true,
 				Description: "A mapping of environment variables to set inside the workspace.",
 
-				Type:        schema.TypeMap,
-				Optional:    true,
+				Type:     schema.TypeMap,
+				Optional: true,
 			},
 			"os": {
 				Type:         schema.TypeString,
 				ForceNew:     true,
 				Required:     true,
-				Description:  "The operating system the agent will run on. Must be one of: `\"linux\"`, `\"darwin\"`, or `\"windows\"`.",
-				ValidateFunc: validation.StringInSlice([]string{"linux", "darwin", "windows"}, false),
+				Description:  "The operating system the agent will run on. Must be one of: `\"linux\"`, `\"darwin\"`, `\"windows\"`, or `\"freebsd\"`.",
+				ValidateFunc: validation.StringInSlice(agentOperatingSystems, false),
 			},
 			"startup_script": {
-				ForceNew:    true,
 				Description: "A script to run after the agent starts. The script should exit when it is done to signal that the agent is ready. This option is an alias for defining a `coder_script` resource with `run_on_start` set to `true`.",
 				Type:        schema.TypeString,
 				Optional:    true,
 			},
 			"shutdown_script": {
 				Type:        schema.TypeString,
-				ForceNew:    true,
 				Optional:    true,
-				Description: "A script to run before the agent is stopped. The script should exit when it is done to signal that the workspace can be stopped. This option is an alias for defining a `coder_script` resource with `run_on_stop` set to `true`.",
+				Description: "A script to run before the agent is stopped. The script should exit when it is done to signal that the workspace can be stopped, and is stopped after `shutdown_grace_period` seconds otherwise. This option is an alias for defining a `coder_script` resource with `run_on_stop` set to `true`.",
 			},
 			"token": {
 				ForceNew:    true,
@@ -160,27 +238,69 @@ func agentResource() *schema.Resource {
 			"connection_timeout": {
 				Type:         schema.TypeInt,
 				Default:      120,
-				ForceNew:     true,
 				Optional:     true,
 				Description:  "Time in seconds until the agent is marked as timed out when a connection with the server cannot be established. A value of zero never marks the agent as timed out.",
 				ValidateFunc: validation.IntAtLeast(0),
 			},
+			"startup_timeout": {
+				Type:         schema.TypeInt,
+				Default:      0,
+				Optional:     true,
+				Description:  "Time in seconds the startup scripts may run before the agent's startup is marked as timed out, and `on_startup_failure` applies. A value of zero never times out. Cannot exceed 86400 (one day).",
+				ValidateFunc: validation.IntBetween(0, 86400),
+			},
+			"on_startup_failure": {
+				Type:     schema.TypeString,
+				Default:  "continue",
+				Optional: true,
+				Description: "What happens when a startup script fails or `startup_timeout` is exceeded. When set to `\"continue\"`, the agent reports the error and remains usable. " +
+					"When set to `\"fail\"`, the agent is marked as failed and the workspace is not ready. When set to `\"stop\"`, the workspace is stopped.",
+				ValidateFunc: validation.StringInSlice([]string{"continue", "fail", "stop"}, false),
+			},
+			"shutdown_grace_period": {
+				Type:         schema.TypeInt,
+				Default:      300,
+				Optional:     true,
+				Description:  "Time in seconds the agent waits for the `shutdown_script` and shutdown `coder_script` resources to exit before it is stopped. Must be between 0 and 3600 (one hour).",
+				ValidateFunc: validation.IntBetween(0, 3600),
+			},
+			"reconnect_backoff": {
+				Type:        schema.TypeList,
+				Description: "The exponential backoff between attempts of the agent to reconnect to the server after losing its connection.",
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"min": {
+							Type:         schema.TypeInt,
+							Description:  "The delay in seconds before the first reconnection attempt. Must be between 1 and 300.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntBetween(1, 300),
+						},
+						"max": {
+							Type:         schema.TypeInt,
+							Description:  "The maximum delay in seconds between reconnection attempts. Must be between 1 and 3600, and not less than `min`.",
+							Optional:     true,
+							Default:      60,
+							ValidateFunc: validation.IntBetween(1, 3600),
+						},
+					},
+				},
+			},
 			"troubleshooting_url": {
 				Type:        schema.TypeString,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "A URL to a document with instructions for troubleshooting problems with the agent.",
 			},
 			"motd_file": {
 				Type:        schema.TypeString,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "The path to a file within the workspace containing a message to display to users when they login via SSH. A typical value would be `\"/etc/motd\"`.",
 			},
 			"startup_script_behavior": {
 				Type:         schema.TypeString,
 				Default:      "non-blocking",
-				ForceNew:     true,
 				Optional:     true,
 				Description:  "This option sets the behavior of the `startup_script`. When set to `\"blocking\"`, the `startup_script` must exit before the workspace is ready. When set to `\"non-blocking\"`, the `startup_script` may run in the background and the workspace will be ready immediately. Default is `\"non-blocking\"`, although `\"blocking\"` is recommended. This option is an alias for defining a `coder_script` resource with `start_blocks_login` set to `true` (blocking).",
 				ValidateFunc: validation.StringInSlice([]string{"blocking", "non-blocking"}, false),
@@ -188,26 +308,22 @@ func agentResource() *schema.Resource {
 			"metadata": {
 				Type:        schema.TypeList,
 				Description: "Each `metadata` block defines a single item consisting of a key/value pair. This feature is in alpha and may break in future releases.",
-				ForceNew:    true,
 				Optional:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"key": {
 							Type:        schema.TypeString,
 							Description: "The key of this metadata item.",
-							ForceNew:    true,
 							Required:    true,
 						},
 						"display_name": {
 							Type:        schema.TypeString,
 							Description: "The user-facing name of this value.",
-							ForceNew:    true,
 							Optional:    true,
 						},
 						"script": {
 							Type:        schema.TypeString,
 							Description: "The script that retrieves the value of this metadata item.",
-							ForceNew:    true,
 							Required:    true,
 							Elem: &schema.Schema{
 								Type: schema.TypeString,
@@ -216,81 +332,123 @@ func agentResource() *schema.Resource {
 						"timeout": {
 							Type:        schema.TypeInt,
 							Description: "The maximum time the command is allowed to run in seconds.",
-							ForceNew:    true,
 							Optional:    true,
 						},
 						"interval": {
 							Type:        schema.TypeInt,
 							Description: "The interval in seconds at which to refresh this metadata item. ",
-							ForceNew:    true,
 							Required:    true,
 						},
 						"order": {
 							Type:        schema.TypeInt,
 							Description: "The order determines the position of agent metadata in the UI presentation. The lowest order is shown first and metadata with equal order are sorted by key (ascending order).",
-							ForceNew:    true,
 							Optional:    true,
 						},
 					},
 				},
 			},
-			"display_apps": {
-				Type:        schema.TypeSet,
-				Description: "The list of built-in apps to display in the agent bar.",
-				ForceNew:    true,
-				Optional:    true,
-				MaxItems:    1,
-				Computed:    true,
+			"readiness_probe": {
+				Type: schema.TypeList,
+				Description: "Determines when the agent is ready, in addition to `startup_script_behavior`. " +
+					"The agent runs the probe once its startup scripts have started, and the workspace is marked ready " +
+					"once the probe succeeds `success_threshold` times in a row. Exactly one of `command`, `http` or `tcp` must be set.",
+				Optional: true,
+				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"vscode": {
-							Type:        schema.TypeBool,
-							Description: "Display the VSCode Desktop app in the agent bar.",
-							ForceNew:    true,
+						"command": {
+							Type:        schema.TypeString,
+							Description: "A script that succeeds when the agent is ready, by exiting with status 0.",
 							Optional:    true,
-							Default:     true,
 						},
-						"vscode_insiders": {
-							Type:        schema.TypeBool,
-							Description: "Display the VSCode Insiders app in the agent bar.",
-							ForceNew:    true,
+						"http": {
+							Type:        schema.TypeList,
+							Description: "An HTTP endpoint that succeeds when the agent is ready, by responding with a status code less than 400.",
 							Optional:    true,
-							Default:     false,
+							MaxItems:    1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"url": {
+										Type:         schema.TypeString,
+										Description:  "The URL to request from the agent, e.g. `http://localhost:8080/healthz`.",
+										Required:     true,
+										ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+									},
+								},
+							},
 						},
-						"web_terminal": {
-							Type:        schema.TypeBool,
-							Description: "Display the web terminal app in the agent bar.",
-							ForceNew:    true,
+						"tcp": {
+							Type:        schema.TypeList,
+							Description: "A TCP address that succeeds when the agent is ready, by accepting a connection.",
 							Optional:    true,
-							Default:     true,
+							MaxItems:    1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"address": {
+										Type:         schema.TypeString,
+										Description:  "The address to connect to from the agent, in `host:port` form, e.g. `localhost:5432`.",
+										Required:     true,
+										ValidateFunc: validateHostPort,
+									},
+								},
+							},
 						},
-						"port_forwarding_helper": {
-							Type:        schema.TypeBool,
-							Description: "Display the port-forwarding helper button in the agent bar.",
-							ForceNew:    true,
-							Optional:    true,
-							Default:     true,
+						"initial_delay": {
+							Type:         schema.TypeInt,
+							Description:  "The number of seconds to wait before running the probe for the first time.",
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
 						},
-						"ssh_helper": {
-							Type:        schema.TypeBool,
-							Description: "Display the SSH helper button in the agent bar.",
-							ForceNew:    true,
-							Optional:    true,
-							Default:     true,
+						"interval": {
+							Type:         schema.TypeInt,
+							Description:  "The interval in seconds at which to run the probe.",
+							Optional:     true,
+							Default:      10,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"timeout": {
+							Type:         schema.TypeInt,
+							Description:  "The maximum time the probe is allowed to run in seconds. Must not exceed `interval`.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"success_threshold": {
+							Type:         schema.TypeInt,
+							Description:  "The number of consecutive successes after which the agent is ready.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+						"failure_threshold": {
+							Type:         schema.TypeInt,
+							Description:  "The number of consecutive failures after which the agent is no longer ready.",
+							Optional:     true,
+							Default:      3,
+							ValidateFunc: validation.IntAtLeast(1),
 						},
 					},
 				},
 			},
+			"display_apps": {
+				Type:        schema.TypeSet,
+				Description: "The list of built-in apps to display in the agent bar.",
+				Optional:    true,
+				MaxItems:    1,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: displayAppsSchema(),
+				},
+			},
 			"order": {
 				Type:        schema.TypeInt,
 				Description: "The order determines the position of agents in the UI presentation. The lowest order is shown first and agents with equal order are sorted by name (ascending order).",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"resources_monitoring": {
 				Type:        schema.TypeSet,
 				Description: "The resources monitoring configuration for this agent.",
-				ForceNew:    true,
 				Optional:    true,
 				MaxItems:    1,
 				Elem: &schema.Resource{
@@ -298,7 +456,6 @@ func agentResource() *schema.Resource {
 						"memory": {
 							Type:        schema.TypeSet,
 							Description: "The memory monitoring configuration for this agent.",
-							ForceNew:    true,
 							Optional:    true,
 							MaxItems:    1,
 							Elem: &schema.Resource{
@@ -306,13 +463,11 @@ func agentResource() *schema.Resource {
 									"enabled": {
 										Type:        schema.TypeBool,
 										Description: "Enable memory monitoring for this agent.",
-										ForceNew:    true,
 										Required:    true,
 									},
 									"threshold": {
 										Type:         schema.TypeInt,
 										Description:  "The memory usage threshold in percentage at which to trigger an alert. Value should be between 0 and 100.",
-										ForceNew:     true,
 										Required:     true,
 										ValidateFunc: validation.IntBetween(0, 100),
 									},
@@ -322,52 +477,158 @@ func agentResource() *schema.Resource {
 						"volume": {
 							Type:        schema.TypeSet,
 							Description: "The volumes monitoring configuration for this agent.",
-							ForceNew:    true,
 							Optional:    true,
 							Elem: &schema.Resource{
 								Schema: map[string]*schema.Schema{
 									"path": {
-										Type:        schema.TypeString,
-										Description: "The path of the volume to monitor.",
-										ForceNew:    true,
-										Required:    true,
-										ValidateDiagFunc: func(i interface{}, s cty.Path) diag.Diagnostics {
-											path, ok := i.(string)
-											if !ok {
-												return diag.Errorf("volume path must be a string")
-											}
-											if path == "" {
-												return diag.Errorf("volume path must not be empty")
-											}
-
-											if !filepath.IsAbs(i.(string)) {
-												return diag.Errorf("volume path must be an absolute path")
-											}
-
-											return nil
-										},
+										Type:             schema.TypeString,
+										Description:      "The path of the volume to monitor.",
+										Required:         true,
+										ValidateDiagFunc: validateMonitorPath("volume"),
 									},
 									"enabled": {
 										Type:        schema.TypeBool,
 										Description: "Enable volume monitoring for this agent.",
-										ForceNew:    true,
 										Required:    true,
 									},
 									"threshold": {
 										Type:         schema.TypeInt,
 										Description:  "The volume usage threshold in percentage at which to trigger an alert. Value should be between 0 and 100.",
-										ForceNew:     true,
 										Required:     true,
 										ValidateFunc: validation.IntBetween(0, 100),
 									},
 								},
 							},
 						},
+						"cpu": {
+							Type:        schema.TypeSet,
+							Description: "The CPU monitoring configuration for this agent.",
+							Optional:    true,
+							MaxItems:    1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"enabled": {
+										Type:        schema.TypeBool,
+										Description: "Enable CPU monitoring for this agent.",
+										Required:    true,
+									},
+									"threshold": {
+										Type:         schema.TypeInt,
+										Description:  "The CPU usage threshold in percentage at which to trigger an alert. Value should be between 0 and 100.",
+										Required:     true,
+										ValidateFunc: validation.IntBetween(0, 100),
+									},
+									"window": {
+										Type:         schema.TypeInt,
+										Description:  "The number of seconds over which CPU usage is averaged before it is compared to the threshold.",
+										Optional:     true,
+										Default:      60,
+										ValidateFunc: validation.IntAtLeast(1),
+									},
+									"duration": monitorDurationSchema(),
+									"severity": monitorSeveritySchema(),
+								},
+							},
+						},
+						"inodes": {
+							Type:        schema.TypeSet,
+							Description: "The inodes monitoring configuration for this agent, per volume.",
+							Optional:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"path": {
+										Type:             schema.TypeString,
+										Description:      "The path of the volume to monitor.",
+										Required:         true,
+										ValidateDiagFunc: validateMonitorPath("inodes"),
+									},
+									"enabled": {
+										Type:        schema.TypeBool,
+										Description: "Enable inodes monitoring for this volume.",
+										Required:    true,
+									},
+									"threshold": {
+										Type:         schema.TypeInt,
+										Description:  "The inodes usage threshold in percentage at which to trigger an alert. Value should be between 0 and 100.",
+										Required:     true,
+										ValidateFunc: validation.IntBetween(0, 100),
+									},
+									"duration": monitorDurationSchema(),
+									"severity": monitorSeveritySchema(),
+								},
+							},
+						},
+						"network": {
+							Type:        schema.TypeSet,
+							Description: "The network throughput monitoring configuration for this agent, per network interface.",
+							Optional:    true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"interface": {
+										Type:         schema.TypeString,
+										Description:  "The name of the network interface to monitor, e.g. `eth0`.",
+										Required:     true,
+										ValidateFunc: validation.StringIsNotEmpty,
+									},
+									"enabled": {
+										Type:        schema.TypeBool,
+										Description: "Enable network monitoring for this interface.",
+										Required:    true,
+									},
+									"threshold": {
+										Type:         schema.TypeInt,
+										Description:  "The throughput threshold in megabits per second, received and transmitted combined, at which to trigger an alert.",
+										Required:     true,
+										ValidateFunc: validation.IntAtLeast(1),
+									},
+									"duration": monitorDurationSchema(),
+									"severity": monitorSeveritySchema(),
+								},
+							},
+						},
 					},
 				},
 			},
 		},
 		CustomizeDiff: func(ctx context.Context, rd *schema.ResourceDiff, i any) error {
+			if err := diffAPIKeyScopes(rd); err != nil {
+				return err
+			}
+
+			// init_script is rendered from init_script_vars on update, so its
+			// new value is only known after apply.
+			if rd.Id() != "" && rd.HasChange("init_script_vars") {
+				if err := rd.SetNewComputed("init_script"); err != nil {
+					return err
+				}
+			}
+
+			if rd.HasChange("readiness_probe") {
+				if err := checkReadinessProbe(rd); err != nil {
+					return err
+				}
+			}
+
+			if rd.HasChanges("auth", "workload_identity") {
+				if err := checkWorkloadIdentity(rd); err != nil {
+					return err
+				}
+			}
+
+			if rd.HasChange("reconnect_backoff") && rd.NewValueKnown("reconnect_backoff.0.min") && rd.NewValueKnown("reconnect_backoff.0.max") {
+				minBackoff, _ := rd.Get("reconnect_backoff.0.min").(int)
+				maxBackoff, _ := rd.Get("reconnect_backoff.0.max").(int)
+				if minBackoff > maxBackoff {
+					return xerrors.Errorf("reconnect_backoff min (%d) must not exceed max (%d)", minBackoff, maxBackoff)
+				}
+			}
+
+			if rd.HasChange("display_apps") {
+				if err := checkDisplayAppsOrder(rd); err != nil {
+					return err
+				}
+			}
+
 			if rd.HasChange("metadata") {
 				keys := map[string]bool{}
 				metadata, ok := rd.Get("metadata").([]any)
@@ -410,40 +671,30 @@ func agentResource() *schema.Resource {
 					return xerrors.Errorf("unexpected type %T for resources_monitoring.0.volume, expected []any", rawMonitors)
 				}
 
-				volumes, ok := monitors["volume"].(*schema.Set)
-				if !ok {
-					return xerrors.Errorf("unexpected type %T for resources_monitoring.0.volume, expected []any", monitors["volume"])
-				}
-
-				paths := map[string]bool{}
-				for _, volume := range volumes.List() {
-					obj, ok := volume.(map[string]any)
-					if !ok {
-						return xerrors.Errorf("unexpected type %T for volume, expected map[string]any", volume)
-					}
-
-					// print path for debug purpose
-					path, ok := obj["path"].(string)
-					if !ok {
-						return xerrors.Errorf("unexpected type %T for volume path, expected string", obj["path"])
-					}
-					if paths[path] {
-						return xerrors.Errorf("duplicate volume path %q", path)
+				for _, monitor := range []struct{ block, key string }{
+					{"volume", "path"},
+					{"inodes", "path"},
+					{"network", "interface"},
+				} {
+					if err := checkDuplicateMonitors(monitors, monitor.block, monitor.key); err != nil {
+						return err
 					}
-					paths[path] = true
 				}
 			}
 
 			return nil
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"os", "arch"}, "auth", "api_key_scope", "api_key_scopes")
+	withStateUpgrades(resource, noopStateUpgrade, agentStateUpgradeV1, agentStateUpgradeV2)
+	return resource
 }
 
 func agentInstanceResource() *schema.Resource {
 	return &schema.Resource{
-		Description: "Use this resource to associate an instance ID with an agent for zero-trust " +
-			"authentication. This association is done automatically for `\"google_compute_instance\"`, " +
-			"`\"aws_instance\"`, `\"azurerm_linux_virtual_machine\"`, and " +
+		Description: "Use this resource to associate an instance ID, Kubernetes pod UID or workload identity " +
+			"with an agent for zero-trust authentication. This association is done automatically for " +
+			"`\"google_compute_instance\"`, `\"aws_instance\"`, `\"azurerm_linux_virtual_machine\"`, and " +
 			"`\"azurerm_windows_virtual_machine\"` resources.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			resourceData.SetId(uuid.NewString())
@@ -463,17 +714,443 @@ func agentInstanceResource() *schema.Resource {
 				Required:    true,
 			},
 			"instance_id": {
-				ForceNew:    true,
-				Required:    true,
-				Description: "The instance identifier of a provisioned resource.",
-				Type:        schema.TypeString,
+				ForceNew:     true,
+				Optional:     true,
+				Description:  "The instance identifier of a provisioned resource, for agents using instance identity `auth`.",
+				Type:         schema.TypeString,
+				ExactlyOneOf: []string{"instance_id", "pod_uid", "subject"},
+			},
+			"pod_uid": {
+				ForceNew:     true,
+				Optional:     true,
+				Description:  "The UID of the Kubernetes pod running the agent, for agents using `\"kubernetes-service-account\"` `auth`, e.g. `kubernetes_pod.dev.metadata[0].uid`.",
+				Type:         schema.TypeString,
+				ExactlyOneOf: []string{"instance_id", "pod_uid", "subject"},
+				ValidateFunc: validation.IsUUID,
+			},
+			"subject": {
+				ForceNew:     true,
+				Optional:     true,
+				Description:  "The `sub` claim of the workload's token, for agents using `\"oidc-workload-identity\"` `auth`, e.g. the Nomad workload identity `global:default:dev:agent:agent`.",
+				Type:         schema.TypeString,
+				ExactlyOneOf: []string{"instance_id", "pod_uid", "subject"},
+				ValidateFunc: validation.StringIsNotEmpty,
 			},
 		},
 	}
 }
 
+// agentAPIKeyScopes are the route groups of the agent API that an agent
+// token can be scoped to, and the routes they grant access to.
+var agentAPIKeyScopes = []struct {
+	name        string
+	description string
+}{
+	{"external_auth", "`/external-auth` and `/gitauth`, which return the workspace owner's external auth access tokens"},
+	{"git_ssh_key", "`/gitsshkey`, which returns the workspace owner's Git SSH key"},
+	{"workspace_app_tokens", "issuing tokens to access workspace apps"},
+	{"metadata_read", "reading the results of the agent's `metadata`"},
+	{"metadata_write", "reporting the results of the agent's `metadata`"},
+}
+
+// agentAPIKeyScopePresets maps each api_key_scope to the api_key_scopes it
+// grants.
+var agentAPIKeyScopePresets = map[string][]string{
+	"all":          agentAPIKeyScopeNames(),
+	"no_user_data": {"workspace_app_tokens", "metadata_read", "metadata_write"},
+}
+
+func agentAPIKeyScopeNames() []string {
+	names := make([]string, 0, len(agentAPIKeyScopes))
+	for _, scope := range agentAPIKeyScopes {
+		names = append(names, scope.name)
+	}
+	return names
+}
+
+func agentAPIKeyScopesDescription() string {
+	descriptions := make([]string, 0, len(agentAPIKeyScopes))
+	for _, scope := range agentAPIKeyScopes {
+		descriptions = append(descriptions, fmt.Sprintf("`%s` (%s)", scope.name, scope.description))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// defaultAPIKeyScopes returns the api_key_scopes granted by the api_key_scope
+// preset.
+func defaultAPIKeyScopes(apiKeyScope interface{}) *schema.Set {
+	preset, _ := apiKeyScope.(string)
+	scopes := schema.NewSet(schema.HashString, nil)
+	for _, scope := range agentAPIKeyScopePresets[preset] {
+		scopes.Add(scope)
+	}
+	return scopes
+}
+
+// setDefaultAPIKeyScopes sets api_key_scopes from api_key_scope for agents
+// created or imported before api_key_scopes existed.
+func setDefaultAPIKeyScopes(resourceData *schema.ResourceData) error {
+	scopes, _ := resourceData.Get("api_key_scopes").(*schema.Set)
+	if scopes != nil && scopes.Len() > 0 {
+		return nil
+	}
+	return resourceData.Set("api_key_scopes", defaultAPIKeyScopes(resourceData.Get("api_key_scope")))
+}
+
+// diffAPIKeyScopes plans api_key_scopes from api_key_scope when it is not
+// configured. Otherwise, removing api_key_scopes from the configuration would
+// keep the scopes in the state, since the attribute is computed.
+func diffAPIKeyScopes(rd *schema.ResourceDiff) error {
+	if !rd.GetRawConfig().GetAttr("api_key_scopes").IsNull() {
+		return nil
+	}
+	scopes := defaultAPIKeyScopes(rd.Get("api_key_scope"))
+	current, _ := rd.Get("api_key_scopes").(*schema.Set)
+	if current != nil && sameStrings(current.List(), scopes.List()) {
+		return nil
+	}
+	if err := rd.SetNew("api_key_scopes", scopes.List()); err != nil {
+		return err
+	}
+	if rd.Id() == "" {
+		return nil
+	}
+	return rd.ForceNew("api_key_scopes")
+}
+
+// sameStrings reports whether a and b contain the same strings, in any order.
+func sameStrings(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[interface{}]int{}
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		if counts[v] == 0 {
+			return false
+		}
+		counts[v]--
+	}
+	return true
+}
+
+func monitorDurationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeInt,
+		Description:  "The number of seconds the threshold must be exceeded for before an alert is triggered.",
+		Optional:     true,
+		Default:      0,
+		ValidateFunc: validation.IntAtLeast(0),
+	}
+}
+
+func monitorSeveritySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Description:  "The severity of the alert. Must be one of: `\"warning\"`, `\"critical\"`.",
+		Optional:     true,
+		Default:      "warning",
+		ValidateFunc: validation.StringInSlice([]string{"warning", "critical"}, false),
+	}
+}
+
+// validateMonitorPath validates the path of a resources monitor, which must
+// be absolute.
+func validateMonitorPath(block string) schema.SchemaValidateDiagFunc {
+	return func(i interface{}, _ cty.Path) diag.Diagnostics {
+		path, ok := i.(string)
+		if !ok {
+			return diag.Errorf("%s path must be a string", block)
+		}
+		if path == "" {
+			return diag.Errorf("%s path must not be empty", block)
+		}
+		if !filepath.IsAbs(path) {
+			return diag.Errorf("%s path must be an absolute path", block)
+		}
+		return nil
+	}
+}
+
+// checkDuplicateMonitors returns an error if two monitors of the given block
+// in resources_monitoring have the same key.
+func checkDuplicateMonitors(monitors map[string]any, block, key string) error {
+	set, ok := monitors[block].(*schema.Set)
+	if !ok {
+		return xerrors.Errorf("unexpected type %T for resources_monitoring.0.%s, expected []any", monitors[block], block)
+	}
+	seen := map[string]bool{}
+	for _, monitor := range set.List() {
+		obj, ok := monitor.(map[string]any)
+		if !ok {
+			return xerrors.Errorf("unexpected type %T for %s, expected map[string]any", monitor, block)
+		}
+		value, ok := obj[key].(string)
+		if !ok {
+			return xerrors.Errorf("unexpected type %T for %s %s, expected string", obj[key], block, key)
+		}
+		if seen[value] {
+			return xerrors.Errorf("duplicate %s %s %q", block, key, value)
+		}
+		seen[value] = true
+	}
+	return nil
+}
+
+// displayApps are the built-in apps that can be displayed in the agent bar,
+// in the order they are displayed by default. The apps that predate the
+// registry each have a boolean attribute in display_apps, described by
+// description. The others are enabled by listing their name in
+// display_apps.apps, so that adding an app does not change the schema, and
+// description is the app's label.
+var displayApps = []struct {
+	name        string
+	description string
+	enabled     bool
+	attribute   bool
+}{
+	{"vscode", "Display the VSCode Desktop app in the agent bar.", true, true},
+	{"vscode_insiders", "Display the VSCode Insiders app in the agent bar.", false, true},
+	{"web_terminal", "Display the web terminal app in the agent bar.", true, true},
+	{"port_forwarding_helper", "Display the port-forwarding helper button in the agent bar.", true, true},
+	{"ssh_helper", "Display the SSH helper button in the agent bar.", true, true},
+	{"jetbrains_gateway", "JetBrains Gateway", false, false},
+	{"cursor", "Cursor", false, false},
+	{"windsurf", "Windsurf", false, false},
+	{"zed", "Zed", false, false},
+	{"fleet", "JetBrains Fleet", false, false},
+	{"rdp_helper", "the RDP helper button", false, false},
+}
+
+func displayAppNames() []string {
+	names := make([]string, 0, len(displayApps))
+	for _, app := range displayApps {
+		names = append(names, app.name)
+	}
+	return names
+}
+
+func displayAppsSchema() map[string]*schema.Schema {
+	var mapApps, mapAppNames []string
+	for _, app := range displayApps {
+		if !app.attribute {
+			mapApps = append(mapApps, fmt.Sprintf("`%s` (%s)", app.name, app.description))
+			mapAppNames = append(mapAppNames, app.name)
+		}
+	}
+	appsSchema := map[string]*schema.Schema{
+		"apps": {
+			Type: schema.TypeSet,
+			Description: "The names of the other built-in apps to display in the agent bar, e.g. " +
+				"`[\"cursor\"]`. Apps: " + strings.Join(mapApps, ", ") + ".",
+			Optional: true,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice(mapAppNames, false),
+			},
+		},
+		"order": {
+			Type: schema.TypeList,
+			Description: "The names of the apps in the order they are displayed in the agent bar, e.g. " +
+				"`[\"cursor\", \"web_terminal\"]`. Apps that are not listed are displayed after them, in " +
+				"this order: `" + strings.Join(displayAppNames(), "`, `") + "`.",
+			Optional: true,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice(displayAppNames(), false),
+			},
+		},
+	}
+	for _, app := range displayApps {
+		if !app.attribute {
+			continue
+		}
+		appsSchema[app.name] = &schema.Schema{
+			Type:        schema.TypeBool,
+			Description: app.description,
+			Optional:    true,
+			Default:     app.enabled,
+		}
+	}
+	return appsSchema
+}
+
+// displayAppsDefaults returns the value of display_apps when it is not
+// configured.
+func displayAppsDefaults() map[string]interface{} {
+	defaults := map[string]interface{}{
+		"order": []interface{}{},
+	}
+	apps := []interface{}{}
+	for _, app := range displayApps {
+		if app.attribute {
+			defaults[app.name] = app.enabled
+		} else if app.enabled {
+			apps = append(apps, app.name)
+		}
+	}
+	defaults["apps"] = apps
+	return defaults
+}
+
+// setDefaultDisplayApps sets display_apps to the default set of apps if it is
+// not configured.
+func setDefaultDisplayApps(resourceData *schema.ResourceData) error {
+	if _, ok := resourceData.GetOk("display_apps"); ok {
+		return nil
+	}
+	return resourceData.Set("display_apps", []interface{}{displayAppsDefaults()})
+}
+
+// checkDisplayAppsOrder returns an error if an app is listed more than once
+// in display_apps.order. It reads the configuration, since the planned
+// display_apps may not be known yet.
+func checkDisplayAppsOrder(rd *schema.ResourceDiff) error {
+	apps := rd.GetRawConfig().GetAttr("display_apps")
+	if apps.IsNull() || !apps.IsKnown() {
+		return nil
+	}
+	for it := apps.ElementIterator(); it.Next(); {
+		_, app := it.Element()
+		order := app.GetAttr("order")
+		if order.IsNull() || !order.IsWhollyKnown() {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, name := range order.AsValueSlice() {
+			if name.IsNull() {
+				continue
+			}
+			if seen[name.AsString()] {
+				return xerrors.Errorf("duplicate app %q in display_apps order", name.AsString())
+			}
+			seen[name.AsString()] = true
+		}
+	}
+	return nil
+}
+
+// workloadIdentityAuthTypes are the values of auth which authenticate with
+// the token of the agent's workload, configured by workload_identity.
+var workloadIdentityAuthTypes = []string{"kubernetes-service-account", "oidc-workload-identity"}
+
+// checkWorkloadIdentity returns an error if workload_identity is set for an
+// auth type which does not use it, or if it misses an attribute required by
+// the auth type.
+func checkWorkloadIdentity(rd *schema.ResourceDiff) error {
+	if !rd.NewValueKnown("auth") {
+		return nil
+	}
+	auth, _ := rd.Get("auth").(string)
+	identities := rd.GetRawConfig().GetAttr("workload_identity")
+	if identities.IsNull() || !identities.IsKnown() || identities.LengthInt() == 0 {
+		if auth == "oidc-workload-identity" {
+			return xerrors.Errorf("auth %q requires workload_identity", auth)
+		}
+		return nil
+	}
+	if !slices.Contains(workloadIdentityAuthTypes, auth) {
+		return xerrors.Errorf("workload_identity cannot be set when auth is %q", auth)
+	}
+	if auth != "oidc-workload-identity" {
+		return nil
+	}
+	identity := identities.Index(cty.NumberIntVal(0))
+	for _, name := range []string{"issuer", "token_file"} {
+		if identity.GetAttr(name).IsNull() {
+			return xerrors.Errorf("auth %q requires workload_identity %s", auth, name)
+		}
+	}
+	return nil
+}
+
+// checkReadinessProbe returns an error if readiness_probe does not set
+// exactly one kind of probe, or if its timeout exceeds its interval. It
+// reads the kinds of probe from the configuration, since their values may
+// not be known yet.
+func checkReadinessProbe(rd *schema.ResourceDiff) error {
+	probes := rd.GetRawConfig().GetAttr("readiness_probe")
+	if probes.IsNull() || !probes.IsKnown() || probes.LengthInt() == 0 {
+		return nil
+	}
+	probe := probes.Index(cty.NumberIntVal(0))
+	var kinds []string
+	if !probe.GetAttr("command").IsNull() {
+		kinds = append(kinds, "command")
+	}
+	for _, kind := range []string{"http", "tcp"} {
+		if block := probe.GetAttr(kind); !block.IsNull() && block.IsKnown() && block.LengthInt() > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) != 1 {
+		return xerrors.Errorf("readiness_probe must set exactly one of command, http or tcp, got %d", len(kinds))
+	}
+
+	if !rd.NewValueKnown("readiness_probe.0.timeout") || !rd.NewValueKnown("readiness_probe.0.interval") {
+		return nil
+	}
+	timeout, _ := rd.Get("readiness_probe.0.timeout").(int)
+	interval, _ := rd.Get("readiness_probe.0.interval").(int)
+	if timeout > interval {
+		return xerrors.Errorf("readiness_probe timeout (%d) must not exceed its interval (%d)", timeout, interval)
+	}
+	return nil
+}
+
+// validateHostPort validates an address in host:port form.
+func validateHostPort(i interface{}, k string) ([]string, []error) {
+	address, ok := i.(string)
+	if !ok {
+		return nil, []error{xerrors.Errorf("expected type of %s to be string", k)}
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, []error{xerrors.Errorf("expected %s to be in host:port form, got %q: %w", k, address, err)}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return nil, []error{xerrors.Errorf("expected %s to have a port between 1 and 65535, got %q", k, port)}
+	}
+	return nil, nil
+}
+
+// initScriptVarNameRegex matches names of init_script_vars, which use the
+// same convention as environment variables so they can share names.
+var initScriptVarNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// validateInitScriptVars rejects names that are not valid placeholders, or
+// that would override the placeholders set by the provider.
+func validateInitScriptVars(value interface{}, p cty.Path) diag.Diagnostics {
+	vars, ok := value.(map[string]interface{})
+	if !ok {
+		return diag.Errorf("init_script_vars was unexpected type %q", reflect.TypeOf(value))
+	}
+	var diags diag.Diagnostics
+	for name := range vars {
+		switch {
+		case !initScriptVarNameRegex.MatchString(name):
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("invalid init_script_vars name %q", name),
+				Detail:        fmt.Sprintf("names must match %q", initScriptVarNameRegex.String()),
+				AttributePath: p,
+			})
+		case name == "ACCESS_URL" || name == "AUTH_TYPE" || name == "AGENT_ID":
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       fmt.Sprintf("init_script_vars cannot set %q", name),
+				Detail:        "it is substituted by the provider",
+				AttributePath: p,
+			})
+		}
+	}
+	return diags
+}
+
 // updateInitScript fetches parameters from a "coder_agent" to produce the
-// agent script from environment variables.
+// agent script from the build context.
 func updateInitScript(resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 	config, valid := i.(config)
 	if !valid {
@@ -495,20 +1172,47 @@ func updateInitScript(resourceData *schema.ResourceData, i interface{}) diag.Dia
 	if err != nil {
 		return diag.Errorf("parse access url: %s", err)
 	}
-	script := helpers.OptionalEnv(fmt.Sprintf("CODER_AGENT_SCRIPT_%s_%s", operatingSystem, arch))
+	var diags diag.Diagnostics
+	script := config.BuildContext.agentScript(operatingSystem, arch)
 	if script != "" {
-		script = strings.ReplaceAll(script, "${ACCESS_URL}", accessURL.String())
-		script = strings.ReplaceAll(script, "${AUTH_TYPE}", auth)
+		placeholders := []string{
+			"${ACCESS_URL}", accessURL.String(),
+			"${AUTH_TYPE}", auth,
+			"${AGENT_ID}", resourceData.Id(),
+		}
+		vars, _ := resourceData.Get("init_script_vars").(map[string]interface{})
+		for name, value := range vars {
+			value, _ := value.(string)
+			placeholders = append(placeholders, "${"+name+"}", value)
+		}
+		// A single pass, so that values are never substituted themselves.
+		script = strings.NewReplacer(placeholders...).Replace(script)
+	} else if config.BuildContext.BuildID != "" {
+		// Older versions of coderd do not build agents for every platform.
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("No init script for agents on %s/%s", operatingSystem, arch),
+			Detail: fmt.Sprintf("The Coder server did not provide %s, so init_script is empty. "+
+				"Upgrade Coder to a version that builds agents for %s/%s.",
+				agentScriptEnvironmentVariable(operatingSystem, arch), operatingSystem, arch),
+		})
 	}
 	err = resourceData.Set("init_script", script)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return nil
+	return diags
 }
 
-func agentAuthToken(ctx context.Context, agentID string) string {
-	existingToken := helpers.OptionalEnv(RunningAgentTokenEnvironmentVariable(agentID))
+// agentAuthToken returns the token of the agent with the given ID. When a
+// prebuilt workspace is claimed, coderd passes the tokens of its running
+// agents keyed by agent ID. Older versions of coderd pass a single token with
+// an empty agent ID, which is used for every agent without a token of its own.
+func agentAuthToken(ctx context.Context, bc *BuildContext, agentID string) string {
+	existingToken := bc.runningAgentToken(agentID)
+	if existingToken == "" {
+		existingToken = bc.runningAgentToken("")
+	}
 	if existingToken == "" {
 		// Most of the time, we will generate a new token for the agent.
 		// In the case of a prebuilt workspace being claimed, we will override with
@@ -535,7 +1239,9 @@ func agentAuthToken(ctx context.Context, agentID string) string {
 // used immutably. Thus, allowing us to avoid reprovisioning resources that may take a long time
 // to replace.
 //
-// agentID is unused for now, but will be used as soon as we support multiple agents.
+// agentID is the ID of the coder_agent, which is kept when a prebuilt workspace
+// is claimed. A token set for an empty agent ID is used by every agent which
+// has no token of its own.
 func RunningAgentTokenEnvironmentVariable(agentID string) string {
 	sum := sha256.Sum256([]byte(agentID))
 	return "CODER_RUNNING_WORKSPACE_AGENT_TOKEN_" + hex.EncodeToString(sum[:])
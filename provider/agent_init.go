@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	fwpath "github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	// agentInitScriptPath is where the init script is written on Unix-like
+	// systems before it is run.
+	agentInitScriptPath     = "/opt/coder/init"
+	defaultUnixTokenFile    = "/var/lib/coder/agent-token"
+	defaultWindowsTokenFile = `C:\ProgramData\Coder\agent-token`
+)
+
+var _ datasource.DataSourceWithValidateConfig = &agentInitDataSource{}
+
+func newAgentInitDataSource() datasource.DataSource {
+	return &agentInitDataSource{}
+}
+
+// agentInitDataSource renders the init script of a coder_agent as user data
+// for cloud instances. The token is written to a file readable only by the
+// agent's user and passed to the agent with CODER_AGENT_TOKEN_FILE, rather
+// than inline in the environment.
+type agentInitDataSource struct{}
+
+type agentInitDataSourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	InitScript     types.String `tfsdk:"init_script"`
+	Token          types.String `tfsdk:"token"`
+	OS             types.String `tfsdk:"os"`
+	User           types.String `tfsdk:"user"`
+	TokenFile      types.String `tfsdk:"token_file"`
+	CloudInit      types.String `tfsdk:"cloud_init"`
+	MIMEMultipart  types.String `tfsdk:"mime_multipart"`
+	UserData       types.String `tfsdk:"user_data"`
+	UserDataBase64 types.String `tfsdk:"user_data_base64"`
+}
+
+func (*agentInitDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_agent_init"
+}
+
+func (*agentInitDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this data source to render the `init_script` of a `coder_agent` as user data for a " +
+			"cloud instance. The agent token is written to a file that only the agent's user can read, " +
+			"instead of being passed inline in the environment.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "A hash of `user_data`.",
+				Computed:    true,
+			},
+			"init_script": schema.StringAttribute{
+				Description: "The `init_script` of the `coder_agent`.",
+				Required:    true,
+			},
+			"token": schema.StringAttribute{
+				Description: "The `token` of the `coder_agent`.",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"os": schema.StringAttribute{
+				Description: "The `os` of the `coder_agent`. Defaults to `\"linux\"`.",
+				Optional:    true,
+				Computed:    true,
+			},
+			"user": schema.StringAttribute{
+				Description: "The user to run the agent as. The token file is owned by this user. Defaults to " +
+					"the user that runs the user data, which is usually `root` or `SYSTEM`.",
+				Optional: true,
+			},
+			"token_file": schema.StringAttribute{
+				Description: "The path to write the token to. Defaults to `\"" + defaultUnixTokenFile + "\"`, or " +
+					"`\"" + strings.ReplaceAll(defaultWindowsTokenFile, `\`, `\\`) + "\"` on Windows.",
+				Optional: true,
+				Computed: true,
+			},
+			"cloud_init": schema.StringAttribute{
+				Description: "A `#cloud-config` document that writes the token file and the init script, then " +
+					"starts the agent. Empty unless `os` is `\"linux\"` or `\"freebsd\"`.",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"mime_multipart": schema.StringAttribute{
+				Description: "A MIME multipart document with a `text/cloud-config` part that writes the token " +
+					"file and the init script, and a `text/x-shellscript` part that starts the agent. Empty " +
+					"unless `os` is `\"linux\"` or `\"freebsd\"`.",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"user_data": schema.StringAttribute{
+				Description: "User data suitable for `os`: `mime_multipart` on Linux and FreeBSD, a shell " +
+					"script on macOS, and a `<powershell>` block on Windows.",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"user_data_base64": schema.StringAttribute{
+				Description: "`user_data`, base64 encoded.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (*agentInitDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var model agentInitDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if model.OS.IsNull() || model.OS.IsUnknown() {
+		return
+	}
+	if !slices.Contains(agentOperatingSystems, model.OS.ValueString()) {
+		resp.Diagnostics.AddAttributeError(fwpath.Root("os"), "Invalid os",
+			fmt.Sprintf("expected os to be one of %q, got %s", agentOperatingSystems, model.OS.ValueString()))
+	}
+}
+
+func (*agentInitDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var model agentInitDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	init := agentInit{
+		OS:         model.OS.ValueString(),
+		InitScript: model.InitScript.ValueString(),
+		Token:      model.Token.ValueString(),
+		User:       model.User.ValueString(),
+		TokenFile:  model.TokenFile.ValueString(),
+	}
+	if init.OS == "" {
+		init.OS = "linux"
+	}
+	if init.TokenFile == "" {
+		init.TokenFile = defaultUnixTokenFile
+		if init.OS == "windows" {
+			init.TokenFile = defaultWindowsTokenFile
+		}
+	}
+
+	var cloudInit, mimeMultipart, userData string
+	switch init.OS {
+	case "linux", "freebsd":
+		cloudInit = init.cloudConfig(true)
+		mimeMultipart = init.mimeMultipart()
+		userData = mimeMultipart
+	case "windows":
+		userData = init.powershell()
+	default:
+		userData = init.shellScript()
+	}
+
+	sum := sha256.Sum256([]byte(userData))
+	model.ID = types.StringValue(hex.EncodeToString(sum[:]))
+	model.OS = types.StringValue(init.OS)
+	model.TokenFile = types.StringValue(init.TokenFile)
+	model.CloudInit = types.StringValue(cloudInit)
+	model.MIMEMultipart = types.StringValue(mimeMultipart)
+	model.UserData = types.StringValue(userData)
+	model.UserDataBase64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(userData)))
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+// agentInit renders the init script of an agent in the formats accepted as
+// user data by cloud providers.
+type agentInit struct {
+	OS         string
+	InitScript string
+	Token      string
+	// User runs the agent and owns the token file. If empty, the agent runs
+	// as the user running the user data.
+	User      string
+	TokenFile string
+}
+
+// cloudConfig returns a #cloud-config document that writes the token file and
+// the init script. If start is true, it also starts the agent with runcmd.
+// Contents are base64 encoded, and other strings are JSON encoded, which is
+// valid YAML, so that nothing needs to be escaped.
+func (a agentInit) cloudConfig(start bool) string {
+	var b strings.Builder
+	b.WriteString("#cloud-config\nwrite_files:\n")
+	fmt.Fprintf(&b, "  - path: %s\n", yamlString(a.TokenFile))
+	b.WriteString("    permissions: \"0600\"\n")
+	if a.User != "" {
+		fmt.Fprintf(&b, "    owner: %s\n", yamlString(a.User))
+		// The user may be created by the users module, which runs after
+		// write_files unless it is deferred.
+		b.WriteString("    defer: true\n")
+	}
+	b.WriteString("    encoding: b64\n")
+	fmt.Fprintf(&b, "    content: %s\n", base64.StdEncoding.EncodeToString([]byte(a.Token)))
+	fmt.Fprintf(&b, "  - path: %s\n", yamlString(agentInitScriptPath))
+	b.WriteString("    permissions: \"0755\"\n")
+	b.WriteString("    encoding: b64\n")
+	fmt.Fprintf(&b, "    content: %s\n", base64.StdEncoding.EncodeToString([]byte(a.InitScript)))
+	if start {
+		b.WriteString("runcmd:\n")
+		fmt.Fprintf(&b, "  - [sh, -c, %s]\n", yamlString(a.startCommand()))
+	}
+	return b.String()
+}
+
+// mimeMultipart returns a MIME multipart document with a cloud-config part
+// that writes the token file and init script, and a shell script part that
+// starts the agent.
+func (a agentInit) mimeMultipart() string {
+	cloudConfig := a.cloudConfig(false)
+	script := "#!/bin/sh\n" + a.startCommand() + "\n"
+
+	// The boundary must be stable, or the user data would change on every
+	// plan and replace the instance.
+	sum := sha256.Sum256([]byte(cloudConfig + script))
+	boundary := "coder-agent-init-" + hex.EncodeToString(sum[:8])
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.SetBoundary(boundary)
+	for _, part := range []struct {
+		contentType string
+		filename    string
+		content     string
+	}{
+		{"text/cloud-config", "cloud-config.yaml", cloudConfig},
+		{"text/x-shellscript", "coder-agent.sh", script},
+	} {
+		// Writes to a bytes.Buffer cannot fail.
+		pw, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + `; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"7bit"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", part.filename)},
+			"Mime-Version":              {"1.0"},
+		})
+		_, _ = pw.Write([]byte(part.content))
+	}
+	_ = w.Close()
+
+	return fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n", boundary) + body.String()
+}
+
+// shellScript returns a self-contained shell script that writes the token
+// file and init script, then starts the agent.
+func (a agentInit) shellScript() string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	b.WriteString("umask 077\n")
+	fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(path.Dir(a.TokenFile)))
+	fmt.Fprintf(&b, "printf '%%s' %s > %s\n", shellQuote(a.Token), shellQuote(a.TokenFile))
+	if a.User != "" {
+		fmt.Fprintf(&b, "chown %s %s\n", shellQuote(a.User), shellQuote(a.TokenFile))
+	}
+	fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(path.Dir(agentInitScriptPath)))
+	fmt.Fprintf(&b, "cat > %s <<'CODER_AGENT_INIT_SCRIPT'\n%s\nCODER_AGENT_INIT_SCRIPT\n", shellQuote(agentInitScriptPath), strings.TrimSuffix(a.InitScript, "\n"))
+	fmt.Fprintf(&b, "chmod 0755 %s\n", shellQuote(agentInitScriptPath))
+	b.WriteString(a.startCommand() + "\n")
+	return b.String()
+}
+
+// startCommand returns a shell command that runs the init script as the
+// agent's user, with CODER_AGENT_TOKEN_FILE pointing at the token file.
+func (a agentInit) startCommand() string {
+	command := fmt.Sprintf("CODER_AGENT_TOKEN_FILE=%s exec sh %s", shellQuote(a.TokenFile), shellQuote(agentInitScriptPath))
+	if a.User == "" {
+		return command
+	}
+	return fmt.Sprintf("exec su %s -c %s", shellQuote(a.User), shellQuote(command))
+}
+
+// powershell returns a <powershell> block, as run by EC2Launch and
+// cloudbase-init, that writes the token file and runs the init script.
+func (a agentInit) powershell() string {
+	var b strings.Builder
+	b.WriteString("<powershell>\n")
+	fmt.Fprintf(&b, "$tokenFile = %s\n", powershellQuote(a.TokenFile))
+	b.WriteString("New-Item -ItemType Directory -Force -Path (Split-Path -Parent $tokenFile) | Out-Null\n")
+	fmt.Fprintf(&b, "[IO.File]::WriteAllBytes($tokenFile, [Convert]::FromBase64String(%s))\n",
+		powershellQuote(base64.StdEncoding.EncodeToString([]byte(a.Token))))
+	grants := "'SYSTEM:F' 'Administrators:F'"
+	if a.User != "" {
+		grants += " " + powershellQuote(a.User+":R")
+	}
+	fmt.Fprintf(&b, "icacls $tokenFile /inheritance:r /grant:r %s | Out-Null\n", grants)
+	b.WriteString("$env:CODER_AGENT_TOKEN_FILE = $tokenFile\n")
+	b.WriteString(strings.TrimSuffix(a.InitScript, "\n") + "\n")
+	b.WriteString("</powershell>\n")
+	return b.String()
+}
+
+func yamlString(s string) string {
+	// JSON strings are valid YAML double-quoted scalars.
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
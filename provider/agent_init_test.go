@@ -0,0 +1,211 @@
+package provider_test
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestAgentInit(t *testing.T) {
+	t.Parallel()
+
+	type cloudConfig struct {
+		WriteFiles []struct {
+			Path        string `yaml:"path"`
+			Permissions string `yaml:"permissions"`
+			Owner       string `yaml:"owner"`
+			Defer       bool   `yaml:"defer"`
+			Encoding    string `yaml:"encoding"`
+			Content     string `yaml:"content"`
+		} `yaml:"write_files"`
+		RunCmd [][]string `yaml:"runcmd"`
+	}
+	parseCloudConfig := func(t *testing.T, value string) cloudConfig {
+		require.True(t, strings.HasPrefix(value, "#cloud-config\n"), value)
+		var config cloudConfig
+		require.NoError(t, yaml.Unmarshal([]byte(value), &config))
+		require.Len(t, config.WriteFiles, 2)
+		return config
+	}
+	decode := func(t *testing.T, value string) string {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		require.NoError(t, err)
+		return string(decoded)
+	}
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "CloudInit",
+		Config: `
+		data "coder_agent_init" "dev" {
+			init_script = "#!/bin/sh\necho 'hello'\n"
+			token       = "some-token"
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "os", "linux"),
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "token_file", "/var/lib/coder/agent-token"),
+			resource.TestCheckResourceAttrWith("data.coder_agent_init.dev", "cloud_init", func(value string) error {
+				config := parseCloudConfig(t, value)
+				token := config.WriteFiles[0]
+				require.Equal(t, "/var/lib/coder/agent-token", token.Path)
+				require.Equal(t, "0600", token.Permissions)
+				require.Empty(t, token.Owner)
+				require.Equal(t, "b64", token.Encoding)
+				require.Equal(t, "some-token", decode(t, token.Content))
+				script := config.WriteFiles[1]
+				require.Equal(t, "/opt/coder/init", script.Path)
+				require.Equal(t, "0755", script.Permissions)
+				require.Equal(t, "#!/bin/sh\necho 'hello'\n", decode(t, script.Content))
+				require.Equal(t, [][]string{{"sh", "-c", "CODER_AGENT_TOKEN_FILE='/var/lib/coder/agent-token' exec sh '/opt/coder/init'"}}, config.RunCmd)
+				return nil
+			}),
+			resource.TestCheckResourceAttrPair("data.coder_agent_init.dev", "user_data", "data.coder_agent_init.dev", "mime_multipart"),
+		),
+	}, {
+		Name: "MIMEMultipart",
+		Config: `
+		data "coder_agent_init" "dev" {
+			init_script = "echo hello"
+			token       = "some-token"
+			os          = "freebsd"
+			user        = "coder"
+			token_file  = "/home/coder/.coder-token"
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttrWith("data.coder_agent_init.dev", "mime_multipart", func(value string) error {
+				header, body, ok := strings.Cut(value, "\r\n\r\n")
+				require.True(t, ok)
+				contentType, ok := strings.CutPrefix(header, "Content-Type: ")
+				require.True(t, ok)
+				contentType, _, _ = strings.Cut(contentType, "\r\n")
+				mediaType, params, err := mime.ParseMediaType(contentType)
+				require.NoError(t, err)
+				require.Equal(t, "multipart/mixed", mediaType)
+
+				reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+				part, err := reader.NextPart()
+				require.NoError(t, err)
+				require.Equal(t, `text/cloud-config; charset="utf-8"`, part.Header.Get("Content-Type"))
+				content, err := io.ReadAll(part)
+				require.NoError(t, err)
+				config := parseCloudConfig(t, string(content))
+				require.Equal(t, "/home/coder/.coder-token", config.WriteFiles[0].Path)
+				require.Equal(t, "coder", config.WriteFiles[0].Owner)
+				require.True(t, config.WriteFiles[0].Defer)
+				require.Empty(t, config.RunCmd)
+
+				part, err = reader.NextPart()
+				require.NoError(t, err)
+				require.Equal(t, `text/x-shellscript; charset="utf-8"`, part.Header.Get("Content-Type"))
+				content, err = io.ReadAll(part)
+				require.NoError(t, err)
+				require.Equal(t, "#!/bin/sh\nexec su 'coder' -c 'CODER_AGENT_TOKEN_FILE='\\''/home/coder/.coder-token'\\'' exec sh '\\''/opt/coder/init'\\'''\n", string(content))
+
+				_, err = reader.NextPart()
+				require.ErrorIs(t, err, io.EOF)
+				return nil
+			}),
+			resource.TestCheckResourceAttrWith("data.coder_agent_init.dev", "user_data_base64", func(value string) error {
+				require.True(t, strings.HasPrefix(decode(t, value), "Content-Type: multipart/mixed"))
+				return nil
+			}),
+		),
+	}, {
+		Name: "Windows",
+		Config: `
+		data "coder_agent_init" "dev" {
+			init_script = "Write-Output 'hello'"
+			token       = "some-token"
+			os          = "windows"
+			user        = "coder"
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "token_file", `C:\ProgramData\Coder\agent-token`),
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "cloud_init", ""),
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "mime_multipart", ""),
+			resource.TestCheckResourceAttr("data.coder_agent_init.dev", "user_data", strings.Join([]string{
+				"<powershell>",
+				`$tokenFile = 'C:\ProgramData\Coder\agent-token'`,
+				"New-Item -ItemType Directory -Force -Path (Split-Path -Parent $tokenFile) | Out-Null",
+				"[IO.File]::WriteAllBytes($tokenFile, [Convert]::FromBase64String('" + base64.StdEncoding.EncodeToString([]byte("some-token")) + "'))",
+				"icacls $tokenFile /inheritance:r /grant:r 'SYSTEM:F' 'Administrators:F' 'coder:R' | Out-Null",
+				"$env:CODER_AGENT_TOKEN_FILE = $tokenFile",
+				"Write-Output 'hello'",
+				"</powershell>",
+				"",
+			}, "\n")),
+		),
+	}, {
+		Name: "Darwin",
+		Config: `
+		data "coder_agent_init" "dev" {
+			init_script = "echo hello"
+			token       = "some-token"
+			os          = "darwin"
+		}`,
+		Check: resource.TestCheckResourceAttr("data.coder_agent_init.dev", "user_data", strings.Join([]string{
+			"#!/bin/sh",
+			"set -e",
+			"umask 077",
+			"mkdir -p '/var/lib/coder'",
+			"printf '%s' 'some-token' > '/var/lib/coder/agent-token'",
+			"mkdir -p '/opt/coder'",
+			"cat > '/opt/coder/init' <<'CODER_AGENT_INIT_SCRIPT'",
+			"echo hello",
+			"CODER_AGENT_INIT_SCRIPT",
+			"chmod 0755 '/opt/coder/init'",
+			"CODER_AGENT_TOKEN_FILE='/var/lib/coder/agent-token' exec sh '/opt/coder/init'",
+			"",
+		}, "\n")),
+	}, {
+		Name: "Agent",
+		Config: `
+		resource "coder_agent" "dev" {
+			os   = "linux"
+			arch = "amd64"
+		}
+		data "coder_agent_init" "dev" {
+			init_script = coder_agent.dev.init_script
+			token       = coder_agent.dev.token
+			os          = coder_agent.dev.os
+		}`,
+		Check: resource.TestCheckResourceAttrSet("data.coder_agent_init.dev", "cloud_init"),
+	}, {
+		Name: "InvalidOS",
+		Config: `
+		data "coder_agent_init" "dev" {
+			init_script = "echo hello"
+			token       = "some-token"
+			os          = "plan9"
+		}`,
+		ExpectError: regexp.MustCompile(`expected os to be one of`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config:      tc.Config,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
@@ -3,11 +3,15 @@ package provider_test
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
 )
 
 func TestAgent(t *testing.T) {
@@ -165,6 +169,156 @@ func TestAgent_Instance(t *testing.T) {
 	})
 }
 
+func TestAgent_InstanceWorkloadIdentity(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "PodUID",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "kubernetes-service-account"
+				workload_identity {
+					audience = "coder"
+				}
+			}
+			resource "coder_agent_instance" "dev" {
+				agent_id = coder_agent.dev.id
+				pod_uid = "6a2f41a3-c54c-fce8-32d2-0324e1c32e22"
+			}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "auth", "kubernetes-service-account"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "workload_identity.0.audience", "coder"),
+			resource.TestCheckResourceAttr("coder_agent_instance.dev", "pod_uid", "6a2f41a3-c54c-fce8-32d2-0324e1c32e22"),
+			resource.TestCheckNoResourceAttr("coder_agent_instance.dev", "instance_id"),
+		),
+	}, {
+		Name: "KubernetesDefaults",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "kubernetes-service-account"
+			}`,
+		Check: resource.TestCheckResourceAttr("coder_agent.dev", "workload_identity.#", "0"),
+	}, {
+		Name: "OIDCSubject",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "oidc-workload-identity"
+				workload_identity {
+					issuer = "https://nomad.example.com"
+					audience = "coder.example.com"
+					token_file = "/secrets/nomad_coder.jwt"
+				}
+			}
+			resource "coder_agent_instance" "dev" {
+				agent_id = coder_agent.dev.id
+				subject = "global:default:dev:agent:agent"
+			}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "workload_identity.0.issuer", "https://nomad.example.com"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "workload_identity.0.token_file", "/secrets/nomad_coder.jwt"),
+			resource.TestCheckResourceAttr("coder_agent_instance.dev", "subject", "global:default:dev:agent:agent"),
+		),
+	}, {
+		Name: "OIDCWithoutWorkloadIdentity",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "oidc-workload-identity"
+			}`,
+		ExpectError: regexp.MustCompile(`auth "oidc-workload-identity" requires workload_identity`),
+	}, {
+		Name: "OIDCWithoutIssuer",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "oidc-workload-identity"
+				workload_identity {
+					token_file = "/secrets/nomad_coder.jwt"
+				}
+			}`,
+		ExpectError: regexp.MustCompile(`auth "oidc-workload-identity" requires workload_identity issuer`),
+	}, {
+		Name: "WorkloadIdentityWithToken",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				workload_identity {
+					audience = "coder"
+				}
+			}`,
+		ExpectError: regexp.MustCompile(`workload_identity cannot be set when auth is "token"`),
+	}, {
+		Name: "InsecureIssuer",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+				auth = "oidc-workload-identity"
+				workload_identity {
+					issuer = "http://nomad.example.com"
+					token_file = "/secrets/nomad_coder.jwt"
+				}
+			}`,
+		ExpectError: regexp.MustCompile(`expected "workload_identity\.0\.issuer" to have a url with schema of: "https"`),
+	}, {
+		Name: "MultipleIdentities",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_agent_instance" "dev" {
+				agent_id = coder_agent.dev.id
+				instance_id = "hello"
+				subject = "hello"
+			}`,
+		ExpectError: regexp.MustCompile(`only one of .instance_id,pod_uid,subject. can be specified`),
+	}, {
+		Name: "NoIdentity",
+		Config: `
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_agent_instance" "dev" {
+				agent_id = coder_agent.dev.id
+			}`,
+		ExpectError: regexp.MustCompile(`one of .instance_id,pod_uid,subject. must be specified`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						` + tc.Config,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
+
 func TestAgent_Metadata(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
@@ -211,6 +365,139 @@ func TestAgent_Metadata(t *testing.T) {
 	})
 }
 
+func TestAgent_Update(t *testing.T) {
+	t.Parallel()
+
+	agentConfig := func(os, env string, interval int) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+				url = "https://example.com"
+			}
+			resource "coder_agent" "dev" {
+				os = %q
+				arch = "amd64"
+				env = {
+					hi = %q
+				}
+				metadata {
+					key = "process_count"
+					script = "ps aux | wc -l"
+					interval = %d
+				}
+				display_apps {
+					vscode = false
+				}
+				order = %d
+			}
+			`, os, env, interval, interval)
+	}
+
+	var agentID string
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactory(),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: agentConfig("linux", "one", 5),
+			Check: func(state *terraform.State) error {
+				agent := state.Modules[0].Resources["coder_agent.dev"]
+				require.NotNil(t, agent)
+				agentID = agent.Primary.ID
+				return nil
+			},
+		}, {
+			// Runtime configuration is updated in place.
+			Config: agentConfig("linux", "two", 10),
+			Check: func(state *terraform.State) error {
+				agent := state.Modules[0].Resources["coder_agent.dev"]
+				require.NotNil(t, agent)
+				attr := agent.Primary.Attributes
+				require.Equal(t, agentID, agent.Primary.ID)
+				require.Equal(t, "two", attr["env.hi"])
+				require.Equal(t, "10", attr["metadata.0.interval"])
+				require.Equal(t, "10", attr["order"])
+				require.Equal(t, "false", attr["display_apps.0.vscode"])
+				require.NotEmpty(t, attr["token"])
+				return nil
+			},
+		}, {
+			// Changing the operating system replaces the agent.
+			Config: agentConfig("windows", "two", 10),
+			Check: func(state *terraform.State) error {
+				agent := state.Modules[0].Resources["coder_agent.dev"]
+				require.NotNil(t, agent)
+				require.NotEqual(t, agentID, agent.Primary.ID)
+				return nil
+			},
+		}},
+	})
+}
+
+func TestAgent_RunningAgentToken(t *testing.T) {
+	t.Parallel()
+
+	// Only the main agent is running when the prebuilt workspace is
+	// claimed, so only its token is passed back to the provider.
+	bc := &provider.BuildContext{}
+	var mainID, sidecarID, sidecarToken string
+	token := func(state *terraform.State, name string) string {
+		agent := state.Modules[0].Resources[name]
+		require.NotNil(t, agent)
+		return agent.Primary.Attributes["token"]
+	}
+	config := `
+		provider "coder" {
+			url = "https://example.com"
+		}
+		resource "coder_agent" "main" {
+			os = "linux"
+			arch = "amd64"
+		}
+		resource "coder_agent" "sidecar" {
+			os = "linux"
+			arch = "amd64"
+		}
+		`
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactoryWithBuildContext(bc),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: config,
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_agent.main", &mainID, true),
+				checkResourceID(t, "coder_agent.sidecar", &sidecarID, true),
+				func(state *terraform.State) error {
+					require.NotEqual(t, token(state, "coder_agent.main"), token(state, "coder_agent.sidecar"))
+					sidecarToken = token(state, "coder_agent.sidecar")
+					bc.SetRunningAgentToken(mainID, "main-token")
+					return nil
+				},
+			),
+		}, {
+			Config: config,
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_agent.main", &mainID, true),
+				checkResourceID(t, "coder_agent.sidecar", &sidecarID, true),
+				func(state *terraform.State) error {
+					require.Equal(t, "main-token", token(state, "coder_agent.main"))
+					require.NotEqual(t, "main-token", token(state, "coder_agent.sidecar"))
+					require.NotEqual(t, sidecarToken, token(state, "coder_agent.sidecar"))
+					// Older versions of coderd pass a single token for
+					// every agent.
+					bc.SetRunningAgentToken("", "shared-token")
+					return nil
+				},
+			),
+		}, {
+			Config: config,
+			Check: func(state *terraform.State) error {
+				require.Equal(t, "main-token", token(state, "coder_agent.main"))
+				require.Equal(t, "shared-token", token(state, "coder_agent.sidecar"))
+				return nil
+			},
+		}},
+	})
+}
+
 func TestAgent_ResourcesMonitoring(t *testing.T) {
 	t.Parallel()
 
@@ -525,6 +812,365 @@ func TestAgent_ResourcesMonitoring(t *testing.T) {
 			}},
 		})
 	})
+
+	t.Run("CPUInodesNetwork", func(t *testing.T) {
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: `
+					provider "coder" {
+						url = "https://example.com"
+					}
+					resource "coder_agent" "dev" {
+						os = "linux"
+						arch = "amd64"
+						resources_monitoring {
+							cpu {
+								enabled = true
+								threshold = 90
+								window = 300
+								duration = 600
+								severity = "critical"
+							}
+							inodes {
+								path = "/home/coder"
+								enabled = true
+								threshold = 95
+							}
+							network {
+								interface = "eth0"
+								enabled = true
+								threshold = 1000
+								duration = 120
+							}
+						}
+					}`,
+				Check: func(state *terraform.State) error {
+					resource := state.Modules[0].Resources["coder_agent.dev"]
+					require.NotNil(t, resource)
+
+					attr := resource.Primary.Attributes
+					require.Equal(t, "1", attr["resources_monitoring.0.cpu.#"])
+					require.Equal(t, "90", attr["resources_monitoring.0.cpu.0.threshold"])
+					require.Equal(t, "300", attr["resources_monitoring.0.cpu.0.window"])
+					require.Equal(t, "600", attr["resources_monitoring.0.cpu.0.duration"])
+					require.Equal(t, "critical", attr["resources_monitoring.0.cpu.0.severity"])
+					require.Equal(t, "1", attr["resources_monitoring.0.inodes.#"])
+					require.Equal(t, "/home/coder", attr["resources_monitoring.0.inodes.0.path"])
+					require.Equal(t, "95", attr["resources_monitoring.0.inodes.0.threshold"])
+					require.Equal(t, "0", attr["resources_monitoring.0.inodes.0.duration"])
+					require.Equal(t, "warning", attr["resources_monitoring.0.inodes.0.severity"])
+					require.Equal(t, "1", attr["resources_monitoring.0.network.#"])
+					require.Equal(t, "eth0", attr["resources_monitoring.0.network.0.interface"])
+					require.Equal(t, "1000", attr["resources_monitoring.0.network.0.threshold"])
+					require.Equal(t, "120", attr["resources_monitoring.0.network.0.duration"])
+					require.Equal(t, "warning", attr["resources_monitoring.0.network.0.severity"])
+					return nil
+				},
+			}},
+		})
+	})
+
+	for _, tc := range []struct {
+		Name     string
+		Monitors string
+		Error    string
+	}{{
+		Name: "DuplicateInodesPaths",
+		Monitors: `
+			inodes {
+				path = "/volume1"
+				enabled = true
+				threshold = 80
+			}
+			inodes {
+				path = "/volume1"
+				enabled = true
+				threshold = 90
+			}`,
+		Error: `duplicate inodes path "/volume1"`,
+	}, {
+		Name: "DuplicateNetworkInterfaces",
+		Monitors: `
+			network {
+				interface = "eth0"
+				enabled = true
+				threshold = 100
+			}
+			network {
+				interface = "eth0"
+				enabled = true
+				threshold = 100
+				severity = "critical"
+			}`,
+		Error: `duplicate network interface "eth0"`,
+	}, {
+		Name: "NonAbsInodesPath",
+		Monitors: `
+			inodes {
+				path = "volume1"
+				enabled = true
+				threshold = 80
+			}`,
+		Error: `inodes path must be an absolute path`,
+	}, {
+		Name: "MultipleCPU",
+		Monitors: `
+			cpu {
+				enabled = true
+				threshold = 80
+			}
+			cpu {
+				enabled = true
+				threshold = 90
+			}`,
+		Error: `No more than 1 "cpu" blocks are allowed`,
+	}, {
+		Name: "InvalidSeverity",
+		Monitors: `
+			cpu {
+				enabled = true
+				threshold = 80
+				severity = "info"
+			}`,
+		Error: `expected resources_monitoring\.0\.cpu\.0\.severity to be one of \["warning" "critical"\], got info`,
+	}, {
+		Name: "NegativeDuration",
+		Monitors: `
+			network {
+				interface = "eth0"
+				enabled = true
+				threshold = 100
+				duration = -1
+			}`,
+		Error: `expected resources_monitoring\.0\.network\.0\.duration to be at least \(0\), got -1`,
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+							resources_monitoring {
+								` + tc.Monitors + `
+							}
+						}`,
+					ExpectError: regexp.MustCompile(tc.Error),
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_ReadinessProbe(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name        string
+		Probe       string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name:  "Command",
+		Probe: `command = "test -f /tmp/ready"`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.command", "test -f /tmp/ready"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.initial_delay", "0"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.interval", "10"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.timeout", "1"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.success_threshold", "1"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.failure_threshold", "3"),
+		),
+	}, {
+		Name: "HTTP",
+		Probe: `
+			http {
+				url = "http://localhost:8080/healthz"
+			}
+			initial_delay     = 30
+			interval          = 5
+			timeout           = 5
+			success_threshold = 2
+			failure_threshold = 10`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.http.0.url", "http://localhost:8080/healthz"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.initial_delay", "30"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.interval", "5"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.timeout", "5"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.success_threshold", "2"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.failure_threshold", "10"),
+		),
+	}, {
+		Name: "TCP",
+		Probe: `
+			tcp {
+				address = "localhost:5432"
+			}`,
+		Check: resource.TestCheckResourceAttr("coder_agent.dev", "readiness_probe.0.tcp.0.address", "localhost:5432"),
+	}, {
+		Name:        "NoProbe",
+		Probe:       `interval = 5`,
+		ExpectError: regexp.MustCompile(`readiness_probe must set exactly one of command, http or tcp, got 0`),
+	}, {
+		Name: "MultipleProbes",
+		Probe: `
+			command = "true"
+			tcp {
+				address = "localhost:5432"
+			}`,
+		ExpectError: regexp.MustCompile(`readiness_probe must set exactly one of command, http or tcp, got 2`),
+	}, {
+		Name: "TimeoutExceedsInterval",
+		Probe: `
+			command  = "true"
+			interval = 5
+			timeout  = 10`,
+		ExpectError: regexp.MustCompile(`readiness_probe timeout \(10\) must not exceed its interval \(5\)`),
+	}, {
+		Name: "InvalidURL",
+		Probe: `
+			http {
+				url = "localhost:8080"
+			}`,
+		ExpectError: regexp.MustCompile(`expected "readiness_probe\.0\.http\.0\.url" to have a host`),
+	}, {
+		Name: "InvalidAddress",
+		Probe: `
+			tcp {
+				address = "localhost"
+			}`,
+		ExpectError: regexp.MustCompile(`expected readiness_probe\.0\.tcp\.0\.address to be in host:port form`),
+	}, {
+		Name: "InvalidPort",
+		Probe: `
+			tcp {
+				address = "localhost:0"
+			}`,
+		ExpectError: regexp.MustCompile(`expected readiness_probe\.0\.tcp\.0\.address to have a port between 1 and 65535`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+							readiness_probe {
+								` + tc.Probe + `
+							}
+						}`,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
+
+func TestAgent_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "Defaults",
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "startup_timeout", "0"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "on_startup_failure", "continue"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "shutdown_grace_period", "300"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "reconnect_backoff.#", "0"),
+		),
+	}, {
+		Name: "Custom",
+		Config: `
+			startup_timeout       = 600
+			on_startup_failure    = "stop"
+			shutdown_grace_period = 60
+			reconnect_backoff {
+				min = 5
+				max = 300
+			}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "startup_timeout", "600"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "on_startup_failure", "stop"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "shutdown_grace_period", "60"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "reconnect_backoff.0.min", "5"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "reconnect_backoff.0.max", "300"),
+		),
+	}, {
+		Name: "BackoffDefaults",
+		Config: `
+			reconnect_backoff {}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_agent.dev", "reconnect_backoff.0.min", "1"),
+			resource.TestCheckResourceAttr("coder_agent.dev", "reconnect_backoff.0.max", "60"),
+		),
+	}, {
+		Name:        "InvalidOnStartupFailure",
+		Config:      `on_startup_failure = "retry"`,
+		ExpectError: regexp.MustCompile(`expected on_startup_failure to be one of \["continue" "fail" "stop"\], got retry`),
+	}, {
+		Name:        "StartupTimeoutTooLong",
+		Config:      `startup_timeout = 86401`,
+		ExpectError: regexp.MustCompile(`expected startup_timeout to be in the range \(0 - 86400\), got 86401`),
+	}, {
+		Name:        "NegativeShutdownGracePeriod",
+		Config:      `shutdown_grace_period = -1`,
+		ExpectError: regexp.MustCompile(`expected shutdown_grace_period to be in the range \(0 - 3600\), got -1`),
+	}, {
+		Name: "BackoffMinExceedsMax",
+		Config: `
+			reconnect_backoff {
+				min = 120
+			}`,
+		ExpectError: regexp.MustCompile(`reconnect_backoff min \(120\) must not exceed max \(60\)`),
+	}, {
+		Name: "BackoffMaxTooLong",
+		Config: `
+			reconnect_backoff {
+				max = 7200
+			}`,
+		ExpectError: regexp.MustCompile(`expected reconnect_backoff\.0\.max to be in the range \(1 - 3600\), got 7200`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+							` + tc.Config + `
+						}`,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
 }
 
 func TestAgent_MetadataDuplicateKeys(t *testing.T) {
@@ -697,12 +1343,86 @@ func TestAgent_DisplayApps(t *testing.T) {
 							require.Equal(t, "true", resource.Primary.Attributes[key])
 						}
 					}
+					require.Equal(t, "0", resource.Primary.Attributes["display_apps.0.apps.#"])
+					require.Equal(t, "0", resource.Primary.Attributes["display_apps.0.order.#"])
 					return nil
 				},
 			}},
 		})
 	})
 
+	t.Run("Order", func(t *testing.T) {
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: `
+					provider "coder" {
+						url = "https://example.com"
+					}
+					resource "coder_agent" "dev" {
+						os = "linux"
+						arch = "amd64"
+						display_apps {
+							vscode = false
+							apps = ["cursor", "zed", "rdp_helper"]
+							order = ["cursor", "web_terminal", "zed"]
+						}
+					}
+					`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.vscode", "false"),
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.apps.#", "3"),
+					resource.TestCheckTypeSetElemAttr("coder_agent.dev", "display_apps.0.apps.*", "cursor"),
+					resource.TestCheckTypeSetElemAttr("coder_agent.dev", "display_apps.0.apps.*", "zed"),
+					resource.TestCheckTypeSetElemAttr("coder_agent.dev", "display_apps.0.apps.*", "rdp_helper"),
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.order.#", "3"),
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.order.0", "cursor"),
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.order.1", "web_terminal"),
+					resource.TestCheckResourceAttr("coder_agent.dev", "display_apps.0.order.2", "zed"),
+				),
+			}},
+		})
+	})
+
+	t.Run("InvalidOrder", func(t *testing.T) {
+		for _, tc := range []struct {
+			Name  string
+			Order string
+			Error string
+		}{{
+			Name:  "UnknownApp",
+			Order: `["cursor", "fake_app"]`,
+			Error: `expected display_apps\.0\.order\.1 to be one of`,
+		}, {
+			Name:  "Duplicate",
+			Order: `["cursor", "zed", "cursor"]`,
+			Error: `duplicate app "cursor" in display_apps order`,
+		}} {
+			t.Run(tc.Name, func(t *testing.T) {
+				resource.Test(t, resource.TestCase{
+					ProviderFactories: coderFactory(),
+					IsUnitTest:        true,
+					Steps: []resource.TestStep{{
+						Config: `
+							provider "coder" {
+								url = "https://example.com"
+							}
+							resource "coder_agent" "dev" {
+								os = "linux"
+								arch = "amd64"
+								display_apps {
+									order = ` + tc.Order + `
+								}
+							}
+							`,
+						ExpectError: regexp.MustCompile(tc.Error),
+					}},
+				})
+			})
+		}
+	})
+
 	t.Run("InvalidApp", func(t *testing.T) {
 		resource.Test(t, resource.TestCase{
 			ProviderFactories: coderFactory(),
@@ -729,6 +1449,29 @@ func TestAgent_DisplayApps(t *testing.T) {
 			}},
 		})
 	})
+
+	t.Run("InvalidMapApp", func(t *testing.T) {
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				// The apps with their own attribute cannot be set in apps.
+				Config: `
+					provider "coder" {
+						url = "https://example.com"
+					}
+					resource "coder_agent" "dev" {
+						os = "linux"
+						arch = "amd64"
+						display_apps {
+							apps = ["vscode"]
+						}
+					}
+					`,
+				ExpectError: regexp.MustCompile(`expected display_apps\.0\.apps\.\d+ to be one of`),
+			}},
+		})
+	})
 }
 
 // TestAgent_APIKeyScope tests valid states/transitions and invalid values for api_key_scope.
@@ -821,4 +1564,333 @@ func TestAgent_APIKeyScope(t *testing.T) {
 			},
 		})
 	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		t.Parallel()
+
+		resourceName := "coder_agent.dev"
+		agentConfig := func(scopes string) string {
+			return fmt.Sprintf(`
+				provider "coder" {
+					url = "https://example.com"
+				}
+				resource "coder_agent" "dev" {
+					os   = "linux"
+					arch = "amd64"
+					%s
+				}
+				`, scopes)
+		}
+		var agentID string
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				// The default preset grants every scope.
+				Config: agentConfig(""),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, resourceName, &agentID, true),
+					resource.TestCheckResourceAttr(resourceName, "api_key_scopes.#", "5"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "external_auth"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "git_ssh_key"),
+				),
+			}, {
+				Config: agentConfig(`api_key_scope = "no_user_data"`),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, resourceName, &agentID, false),
+					resource.TestCheckResourceAttr(resourceName, "api_key_scopes.#", "3"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "workspace_app_tokens"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "metadata_read"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "metadata_write"),
+				),
+			}, {
+				Config: agentConfig(`api_key_scopes = ["git_ssh_key", "metadata_read"]`),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, resourceName, &agentID, false),
+					resource.TestCheckResourceAttr(resourceName, "api_key_scope", "all"),
+					resource.TestCheckResourceAttr(resourceName, "api_key_scopes.#", "2"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "git_ssh_key"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "api_key_scopes.*", "metadata_read"),
+				),
+			}, {
+				// The scopes are part of the import ID, since they force a
+				// new agent.
+				ResourceName: resourceName,
+				ImportState:  true,
+				ImportStateIdFunc: func(state *terraform.State) (string, error) {
+					return state.RootModule().Resources[resourceName].Primary.ID + ":linux:amd64:token:all:metadata_read,git_ssh_key", nil
+				},
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"token"},
+			}, {
+				ResourceName: resourceName,
+				ImportState:  true,
+				ImportStateIdFunc: func(state *terraform.State) (string, error) {
+					return state.RootModule().Resources[resourceName].Primary.ID + ":linux:amd64:token:all:metadata_read,workspace_owner", nil
+				},
+				ExpectError: regexp.MustCompile(`invalid api_key_scopes "workspace_owner" in import ID`),
+			}, {
+				// Removing api_key_scopes goes back to the preset.
+				Config: agentConfig(""),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, resourceName, &agentID, false),
+					resource.TestCheckResourceAttr(resourceName, "api_key_scopes.#", "5"),
+				),
+			}, {
+				Config:             agentConfig(""),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: false,
+			}},
+		})
+	})
+
+	t.Run("InvalidScopes", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			Name   string
+			Config string
+			Error  string
+		}{{
+			Name:   "UnknownScope",
+			Config: `api_key_scopes = ["external_auth", "workspace_owner"]`,
+			Error:  `expected api_key_scopes\.1 to be one of`,
+		}, {
+			// An empty set cannot be told apart from an unset one, which
+			// grants the scopes of the preset.
+			Name:   "Empty",
+			Config: `api_key_scopes = []`,
+			Error:  `Attribute api_key_scopes requires 1 item minimum`,
+		}, {
+			Name: "Conflict",
+			Config: `
+				api_key_scope  = "no_user_data"
+				api_key_scopes = ["metadata_read"]`,
+			Error: `"api_key_scopes": conflicts with api_key_scope`,
+		}} {
+			t.Run(tc.Name, func(t *testing.T) {
+				t.Parallel()
+
+				resource.Test(t, resource.TestCase{
+					ProviderFactories: coderFactory(),
+					IsUnitTest:        true,
+					Steps: []resource.TestStep{{
+						Config: `
+							provider "coder" {
+								url = "https://example.com"
+							}
+							resource "coder_agent" "dev" {
+								os   = "linux"
+								arch = "amd64"
+								` + tc.Config + `
+							}
+							`,
+						ExpectError: regexp.MustCompile(tc.Error),
+						PlanOnly:    true,
+					}},
+				})
+			})
+		}
+	})
+}
+
+func TestAgent_InitScript(t *testing.T) {
+	t.Parallel()
+
+	bc := &provider.BuildContext{BuildID: uuid.NewString()}
+	for _, platform := range [][2]string{
+		{"linux", "amd64"},
+		{"linux", "riscv64"},
+		{"linux", "ppc64le"},
+		{"freebsd", "amd64"},
+		{"windows", "arm64"},
+	} {
+		bc.SetAgentScript(platform[0], platform[1], fmt.Sprintf("%s %s ${ACCESS_URL} ${AUTH_TYPE}", platform[0], platform[1]))
+	}
+
+	for _, tc := range []struct {
+		OS, Arch string
+		Expected string
+	}{
+		{OS: "linux", Arch: "amd64", Expected: "linux amd64 https://example.com/ token"},
+		{OS: "linux", Arch: "riscv64", Expected: "linux riscv64 https://example.com/ token"},
+		{OS: "linux", Arch: "ppc64le", Expected: "linux ppc64le https://example.com/ token"},
+		{OS: "freebsd", Arch: "amd64", Expected: "freebsd amd64 https://example.com/ token"},
+		{OS: "windows", Arch: "arm64", Expected: "windows arm64 https://example.com/ token"},
+		// Coder did not provide a script for this platform.
+		{OS: "freebsd", Arch: "riscv64", Expected: ""},
+	} {
+		t.Run(tc.OS+"_"+tc.Arch, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactoryWithBuildContext(bc),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os   = %q
+							arch = %q
+						}
+						`, tc.OS, tc.Arch),
+					Check: resource.TestCheckResourceAttr("coder_agent.dev", "init_script", tc.Expected),
+				}},
+			})
+		})
+	}
+
+	t.Run("Vars", func(t *testing.T) {
+		t.Parallel()
+
+		bc := &provider.BuildContext{}
+		bc.SetAgentScript("linux", "amd64", strings.Join([]string{
+			"export CODER_AGENT_ID=${AGENT_ID}",
+			"export HTTPS_PROXY=${HTTPS_PROXY}",
+			"export SSL_CERT_FILE=${CA_BUNDLE}",
+			"curl ${BINARY_URL}coder-linux-amd64",
+			"echo ${HOME}",
+		}, "\n"))
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(bc),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: `
+					provider "coder" {
+						url = "https://example.com"
+					}
+					resource "coder_agent" "dev" {
+						os   = "linux"
+						arch = "amd64"
+						init_script_vars = {
+							HTTPS_PROXY = "http://proxy.internal:3128"
+							BINARY_URL  = "https://mirror.internal/coder/"
+							# Values are not substituted themselves.
+							CA_BUNDLE   = "$${BINARY_URL}"
+						}
+					}
+					`,
+				Check: func(state *terraform.State) error {
+					agent := state.Modules[0].Resources["coder_agent.dev"]
+					require.NotNil(t, agent)
+					require.Equal(t, strings.Join([]string{
+						"export CODER_AGENT_ID=" + agent.Primary.ID,
+						"export HTTPS_PROXY=http://proxy.internal:3128",
+						"export SSL_CERT_FILE=${BINARY_URL}",
+						"curl https://mirror.internal/coder/coder-linux-amd64",
+						// Placeholders that are not set are left for the shell.
+						"echo ${HOME}",
+					}, "\n"), agent.Primary.Attributes["init_script"])
+					return nil
+				},
+			}},
+		})
+	})
+
+	t.Run("UpdateVars", func(t *testing.T) {
+		t.Parallel()
+
+		bc := &provider.BuildContext{}
+		bc.SetAgentScript("linux", "amd64", "echo ${GREETING}")
+
+		config := func(greeting string) string {
+			return fmt.Sprintf(`
+				provider "coder" {
+					url = "https://example.com"
+				}
+				resource "coder_agent" "dev" {
+					os   = "linux"
+					arch = "amd64"
+					init_script_vars = {
+						GREETING = %q
+					}
+				}
+				resource "coder_metadata" "dev" {
+					resource_id = coder_agent.dev.id
+					item {
+						key   = "init_script"
+						value = coder_agent.dev.init_script
+					}
+				}
+				`, greeting)
+		}
+
+		var agentID string
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(bc),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: config("a"),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, "coder_agent.dev", &agentID, true),
+					resource.TestCheckResourceAttr("coder_metadata.dev", "item.0.value", "echo a"),
+				),
+			}, {
+				// The agent is updated in place, and resources which read
+				// init_script get the new script.
+				Config: config("b"),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, "coder_agent.dev", &agentID, true),
+					resource.TestCheckResourceAttr("coder_agent.dev", "init_script", "echo b"),
+					resource.TestCheckResourceAttr("coder_metadata.dev", "item.0.value", "echo b"),
+				),
+			}},
+		})
+	})
+
+	t.Run("InvalidVars", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			Name  string
+			Error string
+		}{
+			{Name: "http_proxy", Error: `invalid init_script_vars name "http_proxy"`},
+			{Name: "ACCESS_URL", Error: `init_script_vars cannot set "ACCESS_URL"`},
+		} {
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: fmt.Sprintf(`
+						provider "coder" {
+						}
+						resource "coder_agent" "dev" {
+							os   = "linux"
+							arch = "amd64"
+							init_script_vars = {
+								%s = "value"
+							}
+						}
+						`, tc.Name),
+					ExpectError: regexp.MustCompile(tc.Error),
+					PlanOnly:    true,
+				}},
+			})
+		}
+	})
+
+	t.Run("InvalidArch", func(t *testing.T) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: `
+					provider "coder" {
+					}
+					resource "coder_agent" "dev" {
+						os   = "freebsd"
+						arch = "s390x"
+					}
+					`,
+				ExpectError: regexp.MustCompile(`expected arch to be one of`),
+				PlanOnly:    true,
+			}},
+		})
+	})
 }
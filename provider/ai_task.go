@@ -2,7 +2,6 @@ package provider
 
 import (
 	"context"
-	"os"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -25,14 +24,17 @@ type AITaskSidebarApp struct {
 const TaskPromptParameterName = "AI Prompt"
 
 func aiTaskResource() *schema.Resource {
-	return &schema.Resource{
-		SchemaVersion: 1,
+	resource := &schema.Resource{
+		SchemaVersion: 2,
 
 		Description: "Use this resource to define Coder tasks.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i any) diag.Diagnostics {
-			var diags diag.Diagnostics
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
 
-			if id, err := uuid.Parse(os.Getenv("CODER_TASK_ID")); err == nil && id != uuid.Nil {
+			if id, err := uuid.Parse(bc.Task.ID); err == nil && id != uuid.Nil {
 				resourceData.SetId(id.String())
 				resourceData.Set("enabled", true)
 			} else {
@@ -40,7 +42,7 @@ func aiTaskResource() *schema.Resource {
 				resourceData.Set("enabled", false)
 			}
 
-			if prompt := os.Getenv("CODER_TASK_PROMPT"); prompt != "" {
+			if prompt := bc.Task.Prompt; prompt != "" {
 				resourceData.Set("prompt", prompt)
 			}
 
@@ -114,15 +116,20 @@ func aiTaskResource() *schema.Resource {
 			},
 		},
 	}
+	withStateUpgrades(resource, noopStateUpgrade, aiTaskStateUpgradeV1)
+	return resource
 }
 
 func taskDatasource() *schema.Resource {
 	return &schema.Resource{
 		Description: "Use this data source to read information about Coder Tasks.",
 		ReadContext: func(ctx context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
-			diags := diag.Diagnostics{}
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
 
-			idStr := os.Getenv("CODER_TASK_ID")
+			idStr := bc.Task.ID
 			if idStr == "" || idStr == uuid.Nil.String() {
 				rd.SetId(uuid.NewString())
 				_ = rd.Set("enabled", false)
@@ -133,7 +140,7 @@ func taskDatasource() *schema.Resource {
 				diags = append(diags, errorAsDiagnostics(err)...)
 			}
 
-			_ = rd.Set("prompt", os.Getenv("CODER_TASK_PROMPT"))
+			_ = rd.Set("prompt", bc.Task.Prompt)
 			return diags
 		},
 		Schema: map[string]*schema.Schema{
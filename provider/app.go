@@ -2,12 +2,18 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
@@ -24,6 +30,9 @@ var (
 	appSlugRegex = regexp.MustCompile(`^[a-z0-9](-?[a-z0-9])*$`)
 )
 
+// shareLevels are the levels apps and ports can be shared at.
+var shareLevels = []string{"owner", "authenticated", "organization", "public"}
+
 const (
 	appDisplayNameMaxLength = 64 // database column limit
 	appGroupNameMaxLength   = 64
@@ -31,42 +40,25 @@ const (
 )
 
 func appResource() *schema.Resource {
-	return &schema.Resource{
-		SchemaVersion: 1,
+	resource := &schema.Resource{
+		SchemaVersion: 2,
 
 		Description: "Use this resource to define shortcuts to access applications in a workspace.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i any) diag.Diagnostics {
 			resourceData.SetId(uuid.NewString())
-
-			diags := diag.Diagnostics{}
-
-			hiddenData := resourceData.Get("hidden")
-			if hidden, ok := hiddenData.(bool); !ok {
-				return diag.Errorf("hidden should be a bool")
-			} else if hidden {
-				if _, ok := resourceData.GetOk("display_name"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`display_name` set when app is hidden",
-					})
-				}
-
-				if _, ok := resourceData.GetOk("icon"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`icon` set when app is hidden",
-					})
-				}
-
-				if _, ok := resourceData.GetOk("order"); ok {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Warning,
-						Summary:  "`order` set when app is hidden",
-					})
-				}
+			if err := setAppGroup(resourceData); err != nil {
+				return diag.FromErr(err)
 			}
-
-			return diags
+			return appHiddenWarnings(resourceData)
+		},
+		// Only agent_id and slug force a new app. Everything else is re-read
+		// by coderd on every build, so it is updated in place to keep the app
+		// ID stable for references such as coder_ai_task.app_id.
+		UpdateContext: func(c context.Context, resourceData *schema.ResourceData, i any) diag.Diagnostics {
+			if err := setAppGroup(resourceData); err != nil {
+				return diag.FromErr(err)
+			}
+			return appHiddenWarnings(resourceData)
 		},
 		ReadContext: func(c context.Context, resourceData *schema.ResourceData, i any) diag.Diagnostics {
 			return nil
@@ -74,6 +66,28 @@ func appResource() *schema.Resource {
 		DeleteContext: func(ctx context.Context, rd *schema.ResourceData, i any) diag.Diagnostics {
 			return nil
 		},
+		CustomizeDiff: func(ctx context.Context, rd *schema.ResourceDiff, i any) error {
+			if rd.HasChange("healthcheck") {
+				if err := checkAppHealthcheck(rd); err != nil {
+					return err
+				}
+			}
+			if err := planAppGroup(rd); err != nil {
+				return err
+			}
+			if rd.HasChange("deeplink") {
+				if err := checkAppDeeplink(rd); err != nil {
+					return err
+				}
+			}
+			if rd.HasChanges("share", "share_groups") && rd.NewValueKnown("share") {
+				share, _ := rd.Get("share").(string)
+				if share != "owner" && share != "organization" && !rd.GetRawConfig().GetAttr("share_groups").IsNull() {
+					return xerrors.Errorf("share_groups cannot be set when share is %q, which already shares the app with every user", share)
+				}
+			}
+			return nil
+		},
 		Schema: map[string]*schema.Schema{
 			"agent_id": {
 				Type:        schema.TypeString,
@@ -89,14 +103,12 @@ func appResource() *schema.Resource {
 					"Conflicts with `subdomain`.",
 				ConflictsWith: []string{"url", "subdomain"},
 				Optional:      true,
-				ForceNew:      true,
 			},
 			"icon": {
 				Type: schema.TypeString,
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons [here](https://github.com/coder/coder/tree/main/site/static/icon). Use a " +
 					"built-in icon with `\"${data.coder_workspace.me.access_url}/icon/<path>\"`.",
-				ForceNew:     true,
 				Optional:     true,
 				ValidateFunc: helpers.ValidateURL,
 			},
@@ -124,7 +136,6 @@ func appResource() *schema.Resource {
 			"display_name": {
 				Type:        schema.TypeString,
 				Description: "A display name to identify the app. Defaults to the slug.",
-				ForceNew:    true,
 				Optional:    true,
 				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
 					valStr, ok := val.(string)
@@ -144,21 +155,20 @@ func appResource() *schema.Resource {
 					"subdomain or whether it will be accessed via a path on Coder. If " +
 					"wildcards have not been setup by the administrator then apps with " +
 					"`subdomain` set to `true` will not be accessible. Defaults to `false`.",
-				ForceNew: true,
 				Optional: true,
 			},
 			"share": {
 				Type: schema.TypeString,
 				Description: "Determines the level which the application " +
 					"is shared at. Valid levels are `\"owner\"` (default), " +
-					"`\"authenticated\"` and `\"public\"`. Level `\"owner\"` disables " +
-					"sharing on the app, so only the workspace owner can " +
+					"`\"authenticated\"`, `\"organization\"` and `\"public\"`. Level `\"owner\"` disables " +
+					"sharing on the app, so only the workspace owner and the members of `share_groups` can " +
 					"access it. Level `\"authenticated\"` shares the app with " +
-					"all authenticated users. Level `\"public\"` shares it with " +
+					"all authenticated users. Level `\"organization\"` shares it with the members of the " +
+					"workspace's organization and of `share_groups`. Level `\"public\"` shares it with " +
 					"any user, including unauthenticated users. Permitted " +
 					"application sharing levels can be configured site-wide " +
 					"via a flag on `coder server` (Enterprise only).",
-				ForceNew: true,
 				Optional: true,
 				Default:  "owner",
 				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
@@ -167,12 +177,22 @@ func appResource() *schema.Resource {
 						return diag.Errorf("expected string, got %T", val)
 					}
 
-					switch valStr {
-					case "owner", "authenticated", "public":
+					if slices.Contains(shareLevels, valStr) {
 						return nil
 					}
 
-					return diag.Errorf("invalid app share %q, must be one of \"owner\", \"authenticated\", \"public\"", valStr)
+					return diag.Errorf("invalid app share %q, must be one of %s", valStr, quoteList(shareLevels))
+				},
+			},
+			"share_groups": {
+				Type: schema.TypeSet,
+				Description: "The names of groups to share the app with, in addition to `share`, e.g. " +
+					"`data.coder_workspace_owner.me.groups` to share it with the owner's teammates. Only valid " +
+					"when `share` is `\"owner\"` or `\"organization\"` (Enterprise only).",
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringIsNotEmpty,
 				},
 			},
 			"url": {
@@ -180,54 +200,152 @@ func appResource() *schema.Resource {
 				Description: "An external url if `external=true` or a URL to be proxied to from inside the workspace. " +
 					"This should be of the form `http://localhost:PORT[/SUBPATH]`. " +
 					"Either `command` or `url` may be specified, but not both.",
-				ForceNew:      true,
 				Optional:      true,
 				ConflictsWith: []string{"command"},
 			},
+			"deeplink": {
+				Type: schema.TypeSet,
+				Description: "Opens a desktop client, such as an IDE or an RDP client, through a URI built when the app " +
+					"is opened, instead of `url`. Conflicts with `url`, `command`, `external`, `subdomain`, " +
+					"`healthcheck` and `share`.",
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{"url", "command", "external", "subdomain", "healthcheck", "share"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"scheme": {
+							Type: schema.TypeString,
+							Description: "The URI scheme registered by the desktop client, e.g. `\"jetbrains-gateway\"` or " +
+								"`\"vscode\"`. Web schemes and schemes that run code in the browser are not allowed.",
+							Required:         true,
+							ValidateDiagFunc: validateDeeplinkScheme,
+						},
+						"template": {
+							Type: schema.TypeString,
+							Description: "The rest of the URI, following `<scheme>://`. It may contain the placeholders " +
+								appDeeplinkPlaceholdersDescription() + ", which are percent-encoded and substituted when " +
+								"the app is opened.",
+							Required:         true,
+							ValidateDiagFunc: validateDeeplinkTemplate,
+						},
+						"folder": {
+							Type:        schema.TypeString,
+							Description: "The folder substituted for `{folder}`. Defaults to the `dir` of the agent.",
+							Optional:    true,
+						},
+						"allow_token": {
+							Type: schema.TypeBool,
+							Description: "Whether `template` may contain `{token}`. The session token is then handed to " +
+								"the desktop client in the URI, where the operating system, the client and its logs may " +
+								"record it, and it grants the client the access of the user opening the app. Only allow " +
+								"it for clients that need it to authenticate.",
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
 			"external": {
 				Type: schema.TypeBool,
 				Description: "Specifies whether `url` is opened on the client machine " +
 					"instead of proxied through the workspace.",
 				Default:       false,
-				ForceNew:      true,
 				Optional:      true,
 				ConflictsWith: []string{"healthcheck", "command", "subdomain", "share"},
 			},
 			"healthcheck": {
 				Type:          schema.TypeSet,
-				Description:   "HTTP health checking to determine the application readiness.",
-				ForceNew:      true,
+				Description:   "Health checking to determine the application readiness.",
 				Optional:      true,
 				MaxItems:      1,
 				ConflictsWith: []string{"command"},
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
+						"type": {
+							Type: schema.TypeString,
+							Description: "The type of health check. Valid types are `\"http\"` (default), which requests `url`, " +
+								"`\"tcp\"`, which connects to `address`, and `\"command\"`, which runs `command` in the workspace.",
+							Optional:     true,
+							Default:      "http",
+							ValidateFunc: validation.StringInSlice([]string{"http", "tcp", "command"}, false),
+						},
 						"url": {
-							Type:        schema.TypeString,
-							Description: "HTTP address used determine the application readiness. A successful health check is a HTTP response code less than 500 returned before `healthcheck.interval` seconds.",
-							ForceNew:    true,
-							Required:    true,
+							Type: schema.TypeString,
+							Description: "HTTP address used determine the application readiness. A successful health check is a HTTP response " +
+								"code in `expected_statuses`, or less than 500 if it is empty, returned before `healthcheck.timeout` seconds. " +
+								"Required when `type` is `\"http\"`.",
+							Optional: true,
+						},
+						"expected_statuses": {
+							Type:        schema.TypeList,
+							Description: "The HTTP response codes of a successful health check, e.g. `[200, 204]`. Only valid when `type` is `\"http\"`.",
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeInt,
+								ValidateFunc: validation.IntBetween(100, 599),
+							},
+						},
+						"headers": {
+							Type:        schema.TypeMap,
+							Description: "Headers to send with each health check request. Only valid when `type` is `\"http\"`.",
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"address": {
+							Type: schema.TypeString,
+							Description: "The address to connect to from the agent, in `host:port` form, e.g. `localhost:50051`. A successful " +
+								"health check is a connection accepted before `healthcheck.timeout` seconds. Required when `type` is `\"tcp\"`.",
+							Optional:     true,
+							ValidateFunc: validateHostPort,
+						},
+						"command": {
+							Type: schema.TypeString,
+							Description: "A script run by the agent, e.g. `pg_isready -h localhost`. A successful health check is an exit " +
+								"status of 0 before `healthcheck.timeout` seconds. Required when `type` is `\"command\"`.",
+							Optional: true,
 						},
 						"interval": {
 							Type:        schema.TypeInt,
 							Description: "Duration in seconds to wait between healthcheck requests.",
-							ForceNew:    true,
 							Required:    true,
 						},
+						"timeout": {
+							Type:         schema.TypeInt,
+							Description:  "Duration in seconds each health check may take. Must not exceed `interval`. A value of zero, the default, uses `interval`.",
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"initial_delay": {
+							Type:         schema.TypeInt,
+							Description:  "Duration in seconds to wait before the first health check.",
+							Optional:     true,
+							Default:      0,
+							ValidateFunc: validation.IntAtLeast(0),
+						},
 						"threshold": {
 							Type:        schema.TypeInt,
 							Description: "Number of consecutive heathcheck failures before returning an unhealthy status.",
-							ForceNew:    true,
 							Required:    true,
 						},
+						"success_threshold": {
+							Type:         schema.TypeInt,
+							Description:  "Number of consecutive successful health checks before returning a healthy status.",
+							Optional:     true,
+							Default:      1,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
 					},
 				},
 			},
 			"group": {
-				Type:        schema.TypeString,
-				Description: "The name of a group that this app belongs to.",
-				ForceNew:    true,
-				Optional:    true,
+				Type:          schema.TypeString,
+				Description:   "The name of a group that this app belongs to. Prefer `group_id`, which is checked against the agent of a `coder_app_group`. Set to the name of that group when `group_id` is set.",
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"group_id"},
 				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
 					valStr, ok := val.(string)
 					if !ok {
@@ -240,17 +358,31 @@ func appResource() *schema.Resource {
 					return nil
 				},
 			},
+			"group_id": {
+				Type:        schema.TypeString,
+				Description: "The `id` of a `coder_app_group` on the same agent that this app belongs to, e.g. `coder_app_group.ides.id`. Reference the group's `id` rather than writing it out, so that Terraform requires the group to exist.",
+				Optional:    true,
+				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
+					valStr, ok := val.(string)
+					if !ok {
+						return diag.Errorf("expected string, got %T", val)
+					}
+
+					if _, _, err := parseAppGroupID(valStr); err != nil {
+						return diag.Errorf("group_id is not the id of a coder_app_group: %s", err)
+					}
+					return nil
+				},
+			},
 			"order": {
 				Type:        schema.TypeInt,
 				Description: "The order determines the position of app in the UI presentation. The lowest order is shown first and apps with equal order are sorted by name (ascending order).",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"hidden": {
 				Type:        schema.TypeBool,
 				Description: "Determines if the app is visible in the UI (minimum Coder version: v2.16).",
 				Default:     false,
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"open_in": {
@@ -258,7 +390,6 @@ func appResource() *schema.Resource {
 				Description: "Determines where the app will be opened. Valid values are `\"tab\"` and `\"slim-window\" (default)`. " +
 					"`\"tab\"` opens in a new tab in the same browser window. " +
 					"`\"slim-window\"` opens a new browser window without navigation controls.",
-				ForceNew: true,
 				Optional: true,
 				Default:  "slim-window",
 				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
@@ -278,7 +409,6 @@ func appResource() *schema.Resource {
 			"tooltip": {
 				Type:        schema.TypeString,
 				Description: "Markdown text that is displayed when hovering over workspace apps.",
-				ForceNew:    true,
 				Optional:    true,
 				ValidateDiagFunc: func(val any, c cty.Path) diag.Diagnostics {
 					valStr, ok := val.(string)
@@ -294,4 +424,245 @@ func appResource() *schema.Resource {
 			},
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"agent_id", "slug"})
+	withStateUpgrades(resource, noopStateUpgrade, appStateUpgradeV1)
+	return resource
+}
+
+// appDeeplinkPlaceholders are the placeholders a deeplink template may
+// contain. coderd substitutes them when the app is opened.
+var appDeeplinkPlaceholders = []struct {
+	name        string
+	description string
+}{
+	{"workspace", "the name of the workspace"},
+	{"agent", "the name of the agent"},
+	{"owner", "the username of the workspace owner"},
+	{"folder", "`deeplink.folder`, or the `dir` of the agent"},
+	{"access_url", "the access URL of the deployment"},
+	{"token", "a session token minted for the user opening the app, only when `allow_token` is set"},
+}
+
+// appDeeplinkBlockedSchemes are the schemes a deeplink cannot use: web
+// schemes should use url and external instead, and the others run code or
+// read files in the browser.
+var appDeeplinkBlockedSchemes = []string{"http", "https", "javascript", "data", "vbscript", "file", "blob"}
+
+var (
+	appDeeplinkSchemeRegex      = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+	appDeeplinkPlaceholderRegex = regexp.MustCompile(`\{[^{}]*\}`)
+)
+
+// appDeeplinkPlaceholderNames returns the placeholders of deeplink templates,
+// including their braces.
+func appDeeplinkPlaceholderNames() []string {
+	names := make([]string, 0, len(appDeeplinkPlaceholders))
+	for _, placeholder := range appDeeplinkPlaceholders {
+		names = append(names, "{"+placeholder.name+"}")
+	}
+	return names
+}
+
+func appDeeplinkPlaceholdersDescription() string {
+	placeholders := make([]string, 0, len(appDeeplinkPlaceholders))
+	for _, placeholder := range appDeeplinkPlaceholders {
+		placeholders = append(placeholders, fmt.Sprintf("`{%s}` (%s)", placeholder.name, placeholder.description))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+func validateDeeplinkScheme(val any, _ cty.Path) diag.Diagnostics {
+	valStr, ok := val.(string)
+	if !ok {
+		return diag.Errorf("expected string, got %T", val)
+	}
+
+	if !appDeeplinkSchemeRegex.MatchString(valStr) {
+		return diag.Errorf("invalid deeplink scheme %q, must start with a lowercase letter and only contain lowercase letters, digits, \"+\", \"-\" and \".\"", valStr)
+	}
+	if slices.Contains(appDeeplinkBlockedSchemes, valStr) {
+		return diag.Errorf("deeplink scheme %q is not allowed, use url and external for web links", valStr)
+	}
+	return nil
+}
+
+// validateDeeplinkTemplate checks that a deeplink template only contains known
+// placeholders, and forms a valid URI once they are substituted.
+func validateDeeplinkTemplate(val any, _ cty.Path) diag.Diagnostics {
+	valStr, ok := val.(string)
+	if !ok {
+		return diag.Errorf("expected string, got %T", val)
+	}
+
+	if valStr == "" {
+		return diag.Errorf("deeplink template must not be empty")
+	}
+	placeholders := appDeeplinkPlaceholderNames()
+	for _, match := range appDeeplinkPlaceholderRegex.FindAllString(valStr, -1) {
+		if !slices.Contains(placeholders, match) {
+			return diag.Errorf("unknown placeholder %q in deeplink template, must be one of %s", match, quoteList(placeholders))
+		}
+	}
+	substituted := appDeeplinkPlaceholderRegex.ReplaceAllString(valStr, "placeholder")
+	if strings.ContainsAny(substituted, "{}") {
+		return diag.Errorf("unbalanced brace in deeplink template %q", valStr)
+	}
+	if _, err := url.Parse("scheme://" + substituted); err != nil {
+		return diag.Errorf("invalid deeplink template %q: %s", valStr, err)
+	}
+	return nil
+}
+
+// checkAppDeeplink returns an error if the deeplink template contains
+// {token} without allow_token. It reads the configuration, since the planned
+// deeplink may not be known yet.
+func checkAppDeeplink(rd *schema.ResourceDiff) error {
+	deeplinks := rd.GetRawConfig().GetAttr("deeplink")
+	if deeplinks.IsNull() || !deeplinks.IsKnown() {
+		return nil
+	}
+	for it := deeplinks.ElementIterator(); it.Next(); {
+		_, deeplink := it.Element()
+		template, allowToken := deeplink.GetAttr("template"), deeplink.GetAttr("allow_token")
+		if template.IsNull() || !template.IsKnown() || !allowToken.IsKnown() {
+			continue
+		}
+		if !strings.Contains(template.AsString(), "{token}") {
+			continue
+		}
+		if allowToken.IsNull() || allowToken.False() {
+			return xerrors.Errorf("deeplink template contains {token}, which puts a session token in the URI; set allow_token = true to allow it")
+		}
+	}
+	return nil
+}
+
+// appHealthcheckAttributes are the attributes of healthcheck that are only
+// valid for a type of health check, and required by the first of them.
+var appHealthcheckAttributes = []struct {
+	typ   string
+	attrs []string
+}{
+	{"http", []string{"url", "expected_statuses", "headers"}},
+	{"tcp", []string{"address"}},
+	{"command", []string{"command"}},
+}
+
+// planAppGroup sets group to the name of the coder_app_group of group_id, and
+// returns an error if that group belongs to another agent. group is computed,
+// so when neither group nor group_id is configured any longer, it is planned
+// as unknown and cleared by setAppGroup.
+func planAppGroup(rd *schema.ResourceDiff) error {
+	config := rd.GetRawConfig()
+	groupID := config.GetAttr("group_id")
+	switch {
+	case !groupID.IsKnown():
+		return rd.SetNewComputed("group")
+	case !groupID.IsNull():
+		groupAgentID, name, err := parseAppGroupID(groupID.AsString())
+		if err != nil {
+			return xerrors.Errorf("group_id is not the id of a coder_app_group: %w", err)
+		}
+		if agentID := config.GetAttr("agent_id"); agentID.IsKnown() && !agentID.IsNull() && groupAgentID != agentID.AsString() {
+			return xerrors.Errorf("coder_app_group %q belongs to agent %q, not to the agent %q of this app", name, groupAgentID, agentID.AsString())
+		}
+		if group, _ := rd.Get("group").(string); group != name {
+			return rd.SetNew("group", name)
+		}
+	case config.GetAttr("group").IsNull():
+		if group, _ := rd.GetChange("group"); group != "" {
+			return rd.SetNewComputed("group")
+		}
+	}
+	return nil
+}
+
+// setAppGroup sets group to the name of the coder_app_group of group_id, or
+// clears it when neither group nor group_id is configured.
+func setAppGroup(rd *schema.ResourceData) error {
+	if groupID, _ := rd.Get("group_id").(string); groupID != "" {
+		_, name, err := parseAppGroupID(groupID)
+		if err != nil {
+			return xerrors.Errorf("group_id is not the id of a coder_app_group: %w", err)
+		}
+		return rd.Set("group", name)
+	}
+	if group, _ := rd.Get("group").(string); group != "" && rd.GetRawConfig().GetAttr("group").IsNull() {
+		return rd.Set("group", "")
+	}
+	return nil
+}
+
+// checkAppHealthcheck returns an error if healthcheck misses the attribute
+// required by its type, sets attributes of another type, or has a timeout
+// that exceeds its interval. It reads the configuration, since the planned
+// set element may not be known yet.
+func checkAppHealthcheck(rd *schema.ResourceDiff) error {
+	healthchecks := rd.GetRawConfig().GetAttr("healthcheck")
+	if healthchecks.IsNull() || !healthchecks.IsKnown() {
+		return nil
+	}
+	for it := healthchecks.ElementIterator(); it.Next(); {
+		_, healthcheck := it.Element()
+		healthcheckType := "http"
+		if value := healthcheck.GetAttr("type"); !value.IsKnown() {
+			continue
+		} else if !value.IsNull() {
+			healthcheckType = value.AsString()
+		}
+		for _, healthcheckAttrs := range appHealthcheckAttributes {
+			for i, attr := range healthcheckAttrs.attrs {
+				isNull := healthcheck.GetAttr(attr).IsNull()
+				if healthcheckAttrs.typ == healthcheckType && i == 0 && isNull {
+					return xerrors.Errorf("healthcheck type %q requires %s", healthcheckType, attr)
+				}
+				if healthcheckAttrs.typ != healthcheckType && !isNull {
+					return xerrors.Errorf("healthcheck %s cannot be set when type is %q", attr, healthcheckType)
+				}
+			}
+		}
+
+		timeout, interval := healthcheck.GetAttr("timeout"), healthcheck.GetAttr("interval")
+		if timeout.IsNull() || !timeout.IsKnown() || interval.IsNull() || !interval.IsKnown() {
+			continue
+		}
+		if timeout.AsBigFloat().Cmp(interval.AsBigFloat()) > 0 {
+			return xerrors.Errorf("healthcheck timeout (%s) must not exceed its interval (%s)",
+				timeout.AsBigFloat().String(), interval.AsBigFloat().String())
+		}
+	}
+	return nil
+}
+
+// appHiddenWarnings warns about attributes that have no effect on hidden apps.
+func appHiddenWarnings(resourceData *schema.ResourceData) diag.Diagnostics {
+	diags := diag.Diagnostics{}
+
+	hiddenData := resourceData.Get("hidden")
+	if hidden, ok := hiddenData.(bool); !ok {
+		return diag.Errorf("hidden should be a bool")
+	} else if hidden {
+		if _, ok := resourceData.GetOk("display_name"); ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "`display_name` set when app is hidden",
+			})
+		}
+
+		if _, ok := resourceData.GetOk("icon"); ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "`icon` set when app is hidden",
+			})
+		}
+
+		if _, ok := resourceData.GetOk("order"); ok {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "`order` set when app is hidden",
+			})
+		}
+	}
+
+	return diags
 }
@@ -0,0 +1,294 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
+)
+
+var (
+	_ resource.ResourceWithConfigure      = &appGroupResource{}
+	_ resource.ResourceWithValidateConfig = &appGroupResource{}
+	_ resource.ResourceWithModifyPlan     = &appGroupResource{}
+	_ resource.ResourceWithImportState    = &appGroupResource{}
+)
+
+func newAppGroupResource() resource.Resource {
+	return &appGroupResource{}
+}
+
+// appGroupResource groups the apps of an agent in the dashboard. Its ID is
+// derived from the agent ID and the group name, so that it is known at plan
+// time and coder_app can check that a group_id belongs to the same agent, and
+// read the group name, without reading other resources.
+type appGroupResource struct {
+	names *appGroupNames
+}
+
+// appGroupNames records the groups planned by the current Terraform
+// operation, to reject two groups with the same name on an agent. The
+// framework provider is configured again for every operation, so each
+// operation starts without groups.
+type appGroupNames struct {
+	mu     sync.Mutex
+	groups map[string]bool
+}
+
+// add records a group, and returns false if the agent already has a group
+// with the same name.
+func (n *appGroupNames) add(agentID, name string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.groups == nil {
+		n.groups = map[string]bool{}
+	}
+	key := agentID + "/" + name
+	if n.groups[key] {
+		return false
+	}
+	n.groups[key] = true
+	return true
+}
+
+type appGroupResourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	AgentID            types.String `tfsdk:"agent_id"`
+	Name               types.String `tfsdk:"name"`
+	DisplayName        types.String `tfsdk:"display_name"`
+	Description        types.String `tfsdk:"description"`
+	Icon               types.String `tfsdk:"icon"`
+	Order              types.Int64  `tfsdk:"order"`
+	CollapsedByDefault types.Bool   `tfsdk:"collapsed_by_default"`
+}
+
+// appGroupID returns the ID of the coder_app_group with the given name on an
+// agent. It encodes the agent ID and the name, so that coder_app can read them
+// at plan time, followed by a checksum that catches a mistyped or edited
+// group_id. Anyone can compute the checksum, so it does not prove that the
+// group exists; referencing coder_app_group.<name>.id does.
+func appGroupID(agentID, name string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(agentID + "/" + name))
+	return payload + "." + appGroupIDChecksum(payload)
+}
+
+func appGroupIDChecksum(payload string) string {
+	sum := sha256.Sum256([]byte("coder_app_group:" + payload))
+	return hex.EncodeToString(sum[:8])
+}
+
+// parseAppGroupID returns the agent ID and name of a coder_app_group ID.
+func parseAppGroupID(id string) (agentID, name string, err error) {
+	payload, checksum, ok := strings.Cut(id, ".")
+	if !ok || checksum != appGroupIDChecksum(payload) {
+		return "", "", fmt.Errorf("%q is not derived from the agent_id and name of a coder_app_group", id)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid coder_app_group ID %q: %w", id, err)
+	}
+	agentID, name, _ = strings.Cut(string(raw), "/")
+	return agentID, name, nil
+}
+
+// parseAppGroupImportID returns the agent ID and name of a coder_app_group
+// import ID.
+func parseAppGroupImportID(id string) (agentID, name string, err error) {
+	agentID, name, ok := strings.Cut(id, "/")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("expected %q to be in the form <agent_id>/<name>", id)
+	}
+	if _, err := uuid.Parse(agentID); err != nil {
+		return "", "", fmt.Errorf("invalid agent ID %q in %q, expected a UUID: %w", agentID, id, err)
+	}
+	return agentID, name, nil
+}
+
+func (*appGroupResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_app_group"
+}
+
+func (*appGroupResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Version: 0,
+		Description: "Use this resource to group the apps of an agent in the dashboard. Apps join the group by " +
+			"setting `group_id` to its `id`.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The ID of this resource, derived from `agent_id` and `name`.",
+				Computed:    true,
+			},
+			"agent_id": schema.StringAttribute{
+				Description: "The `id` property of a `coder_agent` resource to associate with.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: fmt.Sprintf("The name of the group, unique per agent. Cannot be longer than %d characters.", appGroupNameMaxLength),
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"display_name": schema.StringAttribute{
+				Description: "A display name to identify the group. Defaults to the name.",
+				Optional:    true,
+			},
+			"description": schema.StringAttribute{
+				Description: "Markdown text that is displayed when hovering over the group.",
+				Optional:    true,
+			},
+			"icon": schema.StringAttribute{
+				Description: "A URL to an icon that will display in the dashboard. View built-in " +
+					"icons [here](https://github.com/coder/coder/tree/main/site/static/icon). Use a " +
+					"built-in icon with `\"${data.coder_workspace.me.access_url}/icon/<path>\"`.",
+				Optional: true,
+			},
+			"order": schema.Int64Attribute{
+				Description: "The order determines the position of the group among the apps of the agent. The lowest " +
+					"order is shown first and groups with equal order are sorted by name (ascending order).",
+				Optional: true,
+			},
+			"collapsed_by_default": schema.BoolAttribute{
+				Description: "Whether the group is collapsed when the workspace page is opened.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *appGroupResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	r.names, _ = req.ProviderData.(*appGroupNames)
+}
+
+func (*appGroupResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var model appGroupResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !model.Name.IsNull() && !model.Name.IsUnknown() {
+		if name := model.Name.ValueString(); name == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("name"), "Invalid name", "group name must not be empty")
+		} else if len(name) > appGroupNameMaxLength {
+			resp.Diagnostics.AddAttributeError(path.Root("name"), "Invalid name",
+				fmt.Sprintf("group name is too long (max %d characters)", appGroupNameMaxLength))
+		}
+	}
+	if !model.DisplayName.IsNull() && !model.DisplayName.IsUnknown() && len(model.DisplayName.ValueString()) > appDisplayNameMaxLength {
+		resp.Diagnostics.AddAttributeError(path.Root("display_name"), "Invalid display_name",
+			fmt.Sprintf("display name is too long (max %d characters)", appDisplayNameMaxLength))
+	}
+	if !model.Icon.IsNull() && !model.Icon.IsUnknown() {
+		_, errs := helpers.ValidateURL(model.Icon.ValueString(), "icon")
+		for _, err := range errs {
+			resp.Diagnostics.AddAttributeError(path.Root("icon"), "Invalid icon", err.Error())
+		}
+	}
+	if !model.Description.IsNull() && !model.Description.IsUnknown() && len(model.Description.ValueString()) > appTooltipMaxLength {
+		resp.Diagnostics.AddAttributeError(path.Root("description"), "Invalid description",
+			fmt.Sprintf("description is too long (max %d characters)", appTooltipMaxLength))
+	}
+}
+
+// ModifyPlan plans the ID as soon as the agent ID and name are known, and
+// rejects a group whose agent already has a group with the same name.
+func (r *appGroupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var model appGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if model.AgentID.IsUnknown() || model.Name.IsUnknown() {
+		return
+	}
+	agentID, name := model.AgentID.ValueString(), model.Name.ValueString()
+	// Terraform plans a replaced group again without its prior state, so it
+	// is only recorded then. Otherwise renaming a group to the name another
+	// group gives up would be rejected, as TestAppGroupRename checks.
+	var prior appGroupResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	replaced := !req.State.Raw.IsNull() && (prior.AgentID.ValueString() != agentID || prior.Name.ValueString() != name)
+	if r.names == nil {
+		resp.Diagnostics.AddError("Unconfigured provider",
+			"coder_app_group was planned before the provider was configured, so duplicate group names cannot be checked")
+		return
+	}
+	if !replaced && !r.names.add(agentID, name) {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Duplicate group",
+			fmt.Sprintf("agent %q already has a coder_app_group named %q", agentID, name))
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), appGroupID(agentID, name))...)
+}
+
+func (*appGroupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var model appGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	model.ID = types.StringValue(appGroupID(model.AgentID.ValueString(), model.Name.ValueString()))
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (*appGroupResource) Read(context.Context, resource.ReadRequest, *resource.ReadResponse) {
+	// The state is the source of truth, so there is nothing to refresh.
+}
+
+func (*appGroupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var model appGroupResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (*appGroupResource) Delete(context.Context, resource.DeleteRequest, *resource.DeleteResponse) {}
+
+// ImportState imports a group by its agent ID and name, which are every
+// attribute that forces a new group.
+func (*appGroupResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	agentID, name, err := parseAppGroupImportID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, appGroupResourceModel{
+		ID:                 types.StringValue(appGroupID(agentID, name)),
+		AgentID:            types.StringValue(agentID),
+		Name:               types.StringValue(name),
+		DisplayName:        types.StringNull(),
+		Description:        types.StringNull(),
+		Icon:               types.StringNull(),
+		Order:              types.Int64Null(),
+		CollapsedByDefault: types.BoolValue(false),
+	})...)
+}
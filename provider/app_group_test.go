@@ -0,0 +1,400 @@
+package provider_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestAppGroup(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "Defaults",
+		Config: `
+			name = "tools"`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttrPair("coder_app_group.tools", "agent_id", "coder_agent.dev", "id"),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "name", "tools"),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "collapsed_by_default", "false"),
+			resource.TestCheckNoResourceAttr("coder_app_group.tools", "display_name"),
+			resource.TestCheckNoResourceAttr("coder_app_group.tools", "icon"),
+			resource.TestCheckNoResourceAttr("coder_app_group.tools", "order"),
+			resource.TestMatchResourceAttr("coder_app_group.tools", "id", regexp.MustCompile(`^[\w-]+\.[0-9a-f]{16}$`)),
+		),
+	}, {
+		Name: "Custom",
+		Config: `
+			name                 = "tools"
+			display_name         = "Developer Tools"
+			description          = "Editors and **debuggers**."
+			icon                 = "/icon/code.svg"
+			order                = 2
+			collapsed_by_default = true`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_app_group.tools", "display_name", "Developer Tools"),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "description", "Editors and **debuggers**."),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "icon", "/icon/code.svg"),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "order", "2"),
+			resource.TestCheckResourceAttr("coder_app_group.tools", "collapsed_by_default", "true"),
+		),
+	}, {
+		Name: "EmptyName",
+		Config: `
+			name = ""`,
+		ExpectError: regexp.MustCompile(`group name must not be empty`),
+	}, {
+		Name: "LongName",
+		Config: `
+			name = "` + strings.Repeat("a", 65) + `"`,
+		ExpectError: regexp.MustCompile(`group name is too long \(max 64 characters\)`),
+	}, {
+		Name: "LongDisplayName",
+		Config: `
+			name         = "tools"
+			display_name = "` + strings.Repeat("a", 65) + `"`,
+		ExpectError: regexp.MustCompile(`display name is too long \(max 64 characters\)`),
+	}, {
+		Name: "DuplicateName",
+		Config: `
+			name = "tools"
+		}
+		resource "coder_app_group" "duplicate" {
+			agent_id = coder_agent.dev.id
+			name     = "tools"`,
+		ExpectError: regexp.MustCompile(`agent "[^"]+" already has a coder_app_group\s+named "tools"`),
+	}, {
+		Name: "InvalidIcon",
+		Config: `
+			name = "tools"
+			icon = "%%invalid"`,
+		ExpectError: regexp.MustCompile(`invalid URL escape`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "coder_app_group" "tools" {
+							agent_id = coder_agent.dev.id
+							` + tc.Config + `
+						}`,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
+
+func TestAppGroupUpdate(t *testing.T) {
+	t.Parallel()
+
+	groupConfig := func(name, displayName string, collapsed bool) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+				url = "https://example.com"
+			}
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_app_group" "tools" {
+				agent_id             = coder_agent.dev.id
+				name                 = %q
+				display_name         = %q
+				collapsed_by_default = %t
+			}`, name, displayName, collapsed)
+	}
+
+	var groupID string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: groupConfig("tools", "Tools", false),
+			Check:  checkResourceID(t, "coder_app_group.tools", &groupID, true),
+		}, {
+			// The display name and collapsed state are updated in place.
+			Config: groupConfig("tools", "Developer Tools", true),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_app_group.tools", &groupID, true),
+				resource.TestCheckResourceAttr("coder_app_group.tools", "display_name", "Developer Tools"),
+				resource.TestCheckResourceAttr("coder_app_group.tools", "collapsed_by_default", "true"),
+			),
+		}, {
+			// Renaming the group replaces it.
+			Config: groupConfig("ides", "Developer Tools", true),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_app_group.tools", &groupID, false),
+				resource.TestCheckResourceAttr("coder_app_group.tools", "name", "ides"),
+			),
+		}, {
+			ResourceName: "coder_app_group.tools",
+			ImportState:  true,
+			ImportStateIdFunc: func(state *terraform.State) (string, error) {
+				group := state.RootModule().Resources["coder_app_group.tools"].Primary
+				return group.Attributes["agent_id"] + "/" + group.Attributes["name"], nil
+			},
+			ImportStateVerify:       true,
+			ImportStateVerifyIgnore: []string{"display_name", "collapsed_by_default"},
+		}, {
+			ResourceName:  "coder_app_group.tools",
+			ImportState:   true,
+			ImportStateId: "not-a-uuid/tools",
+			ExpectError:   regexp.MustCompile(`invalid agent ID "not-a-uuid" in "not-a-uuid/tools", expected a UUID`),
+		}, {
+			ResourceName:  "coder_app_group.tools",
+			ImportState:   true,
+			ImportStateId: "tools",
+			ExpectError:   regexp.MustCompile(`expected "tools" to be in the form <agent_id>/<name>`),
+		}},
+	})
+}
+
+func TestAppGroupRename(t *testing.T) {
+	t.Parallel()
+
+	groupsConfig := func(first, second string) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+				url = "https://example.com"
+			}
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_app_group" "first" {
+				agent_id = coder_agent.dev.id
+				name     = %q
+			}
+			resource "coder_app_group" "second" {
+				agent_id = coder_agent.dev.id
+				name     = %q
+			}`, first, second)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: groupsConfig("tools", "ides"),
+		}, {
+			// The first group takes the name the second group gives up.
+			Config: groupsConfig("ides", "editors"),
+			Check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("coder_app_group.first", "name", "ides"),
+				resource.TestCheckResourceAttr("coder_app_group.second", "name", "editors"),
+			),
+		}, {
+			// The groups swap names.
+			Config: groupsConfig("editors", "ides"),
+			Check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("coder_app_group.first", "name", "editors"),
+				resource.TestCheckResourceAttr("coder_app_group.second", "name", "ides"),
+			),
+		}, {
+			Config:      groupsConfig("ides", "ides"),
+			ExpectError: regexp.MustCompile(`agent "[^"]+" already has a coder_app_group\s+named "ides"`),
+		}},
+	})
+}
+
+func TestAppGroupName(t *testing.T) {
+	t.Parallel()
+
+	appConfig := func(group string) string {
+		return `
+			provider "coder" {
+				url = "https://example.com"
+			}
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_app_group" "tools" {
+				agent_id = coder_agent.dev.id
+				name     = "tools"
+			}
+			resource "coder_app_group" "ides" {
+				agent_id = coder_agent.dev.id
+				name     = "ides"
+			}
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				` + group + `
+			}`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: appConfig(`group_id = coder_app_group.tools.id`),
+			Check:  resource.TestCheckResourceAttr("coder_app.code", "group", "tools"),
+		}, {
+			Config: appConfig(`group_id = coder_app_group.ides.id`),
+			Check:  resource.TestCheckResourceAttr("coder_app.code", "group", "ides"),
+		}, {
+			Config: appConfig(`group = "legacy"`),
+			Check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("coder_app.code", "group", "legacy"),
+				resource.TestCheckResourceAttr("coder_app.code", "group_id", ""),
+			),
+		}, {
+			Config: appConfig(``),
+			Check:  resource.TestCheckResourceAttr("coder_app.code", "group", ""),
+		}},
+	})
+}
+
+func TestAppGroupID(t *testing.T) {
+	t.Parallel()
+
+	const agentID = "5f2c4a51-8f3e-4f0b-9d8c-7a6b5c4d3e2f"
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "SameAgent",
+		Config: `
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = coder_app_group.dev.id
+			}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttrPair("coder_app.code", "group_id", "coder_app_group.dev", "id"),
+			resource.TestCheckResourceAttr("coder_app.code", "group", "tools"),
+		),
+	}, {
+		// Group names are only unique per agent.
+		Name: "SameNameOtherAgent",
+		Config: `
+			resource "coder_app_group" "other" {
+				agent_id = coder_agent.other.id
+				name     = "tools"
+			}
+			resource "coder_app" "code" {
+				agent_id = coder_agent.other.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = coder_app_group.other.id
+			}`,
+		Check: resource.TestCheckResourceAttr("coder_app.code", "group", "tools"),
+	}, {
+		Name: "OtherAgent",
+		Config: `
+			resource "coder_app" "code" {
+				agent_id = coder_agent.other.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = coder_app_group.dev.id
+			}`,
+		ExpectError: regexp.MustCompile(`coder_app_group "tools" belongs to agent "[^"]+", not to the agent "[^"]+"\s+of\s+this\s+app`),
+	}, {
+		// The group ID is known at plan time when the agent ID is.
+		Name: "OtherAgentPlan",
+		Config: `
+			resource "coder_app_group" "static" {
+				agent_id = "` + agentID + `"
+				name     = "tools"
+			}
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = coder_app_group.static.id
+			}`,
+		ExpectError: regexp.MustCompile(`coder_app_group "tools" belongs to agent "` + agentID + `"`),
+	}, {
+		Name: "NotAGroup",
+		Config: `
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = "tools"
+			}`,
+		ExpectError: regexp.MustCompile(`group_id is not the id of a coder_app_group`),
+	}, {
+		// A group_id in the import ID format is not a group ID.
+		Name: "HandBuilt",
+		Config: `
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group_id = "${coder_agent.dev.id}/typo"
+			}`,
+		ExpectError: regexp.MustCompile(`group_id is not the id of a coder_app_group: "[^"]+/typo" is not\s+derived\s+from\s+the\s+agent_id\s+and\s+name\s+of\s+a\s+coder_app_group`),
+	}, {
+		Name: "ConflictsWithGroup",
+		Config: `
+			resource "coder_app" "code" {
+				agent_id = coder_agent.dev.id
+				slug     = "code"
+				url      = "http://localhost:13337"
+				group    = "tools"
+				group_id = coder_app_group.dev.id
+			}`,
+		ExpectError: regexp.MustCompile(`"group": conflicts with group_id`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "coder_agent" "other" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "coder_app_group" "dev" {
+							agent_id = coder_agent.dev.id
+							name     = "tools"
+						}
+						` + tc.Config,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/require"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
 )
 
 func TestApp(t *testing.T) {
@@ -83,6 +85,58 @@ func TestApp(t *testing.T) {
 		})
 	})
 
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+
+		appConfig := func(slug, displayName, tooltip string) string {
+			return fmt.Sprintf(`
+				provider "coder" {
+				}
+				resource "coder_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "coder_app" "code-server" {
+					agent_id = coder_agent.dev.id
+					slug = %q
+					display_name = %q
+					tooltip = %q
+					url = "http://localhost:13337"
+				}
+				resource "coder_ai_task" "task" {
+					app_id = coder_app.code-server.id
+				}
+				`, slug, displayName, tooltip)
+		}
+
+		var appID, taskID string
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactory(),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: appConfig("code-server", "code-server", "Open VS Code"),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, "coder_app.code-server", &appID, true),
+					checkResourceID(t, "coder_ai_task.task", &taskID, true),
+				),
+			}, {
+				// Editing the app keeps its ID, so references to it are
+				// not replaced.
+				Config: appConfig("code-server", "VS Code", "Open VS Code in the browser"),
+				Check: resource.ComposeTestCheckFunc(
+					checkResourceID(t, "coder_app.code-server", &appID, true),
+					checkResourceID(t, "coder_ai_task.task", &taskID, true),
+					resource.TestCheckResourceAttr("coder_app.code-server", "display_name", "VS Code"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "tooltip", "Open VS Code in the browser"),
+				),
+			}, {
+				// The slug identifies the app, so changing it replaces the app.
+				Config: appConfig("vscode", "VS Code", "Open VS Code in the browser"),
+				Check:  checkResourceID(t, "coder_app.code-server", &appID, false),
+			}},
+		})
+	})
+
 	t.Run("External", func(t *testing.T) {
 		t.Parallel()
 
@@ -163,6 +217,11 @@ func TestApp(t *testing.T) {
 				value:       "authenticated",
 				expectValue: "authenticated",
 			},
+			{
+				name:        "ExplicitOrganization",
+				value:       "organization",
+				expectValue: "organization",
+			},
 			{
 				name:        "ExplicitPublic",
 				value:       "public",
@@ -232,6 +291,112 @@ func TestApp(t *testing.T) {
 		}
 	})
 
+	t.Run("ShareGroups", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name        string
+			share       string
+			groups      string
+			expectError *regexp.Regexp
+		}{
+			{
+				name:   "Owner",
+				share:  "owner",
+				groups: `["platform", "sre"]`,
+			},
+			{
+				name:   "Organization",
+				share:  "organization",
+				groups: `["platform"]`,
+			},
+			{
+				name:        "Authenticated",
+				share:       "authenticated",
+				groups:      `["platform"]`,
+				expectError: regexp.MustCompile(`share_groups cannot be set when share is "authenticated"`),
+			},
+			{
+				name:        "Public",
+				share:       "public",
+				groups:      `["platform"]`,
+				expectError: regexp.MustCompile(`share_groups cannot be set when share is "public"`),
+			},
+			{
+				name:        "EmptyGroup",
+				share:       "owner",
+				groups:      `[""]`,
+				expectError: regexp.MustCompile(`expected "share_groups\.\d+" to not be an empty string`),
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				config := fmt.Sprintf(`
+				provider "coder" {
+				}
+				resource "coder_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "coder_app" "dashboard" {
+					agent_id = coder_agent.dev.id
+					slug = "dashboard"
+					url = "http://localhost:3000"
+					share = %q
+					share_groups = %s
+				}
+				`, c.share, c.groups)
+
+				var check resource.TestCheckFunc
+				if c.expectError == nil {
+					check = resource.TestCheckResourceAttr("coder_app.dashboard", "share", c.share)
+				}
+				resource.Test(t, resource.TestCase{
+					ProviderFactories: coderFactory(),
+					IsUnitTest:        true,
+					Steps: []resource.TestStep{{
+						Config:      config,
+						Check:       check,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+
+		// The groups of the workspace owner can be passed as-is.
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(&provider.BuildContext{
+				Owner: provider.OwnerBuildContext{Groups: []string{"platform", "sre"}},
+			}),
+			IsUnitTest: true,
+			Steps: []resource.TestStep{{
+				Config: `
+				provider "coder" {
+				}
+				data "coder_workspace_owner" "me" {
+				}
+				resource "coder_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "coder_app" "dashboard" {
+					agent_id = coder_agent.dev.id
+					slug = "dashboard"
+					url = "http://localhost:3000"
+					share_groups = data.coder_workspace_owner.me.groups
+				}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_app.dashboard", "share_groups.#", "2"),
+					resource.TestCheckTypeSetElemAttr("coder_app.dashboard", "share_groups.*", "platform"),
+					resource.TestCheckTypeSetElemAttr("coder_app.dashboard", "share_groups.*", "sre"),
+				),
+			}},
+		})
+	})
+
 	t.Run("OpenIn", func(t *testing.T) {
 		t.Parallel()
 
@@ -576,6 +741,293 @@ func TestApp(t *testing.T) {
 		}
 	})
 
+	t.Run("Healthcheck", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name        string
+			healthcheck string
+			check       resource.TestCheckFunc
+			expectError *regexp.Regexp
+		}{
+			{
+				name: "HTTPDefaults",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					interval = 5
+					threshold = 6`,
+				check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.type", "http"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.timeout", "0"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.initial_delay", "0"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.success_threshold", "1"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.expected_statuses.#", "0"),
+				),
+			},
+			{
+				name: "HTTP",
+				healthcheck: `
+					type = "http"
+					url = "http://localhost:13337/healthz"
+					expected_statuses = [200, 204]
+					headers = {
+						Authorization = "Bearer token"
+					}
+					interval = 10
+					timeout = 3
+					initial_delay = 15
+					threshold = 6
+					success_threshold = 2`,
+				check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.expected_statuses.#", "2"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.expected_statuses.1", "204"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.headers.Authorization", "Bearer token"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.timeout", "3"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.initial_delay", "15"),
+					resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.success_threshold", "2"),
+				),
+			},
+			{
+				name: "TCP",
+				healthcheck: `
+					type = "tcp"
+					address = "localhost:50051"
+					interval = 5
+					threshold = 3`,
+				check: resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.address", "localhost:50051"),
+			},
+			{
+				name: "Command",
+				healthcheck: `
+					type = "command"
+					command = "pg_isready -h localhost"
+					interval = 5
+					threshold = 3`,
+				check: resource.TestCheckResourceAttr("coder_app.code-server", "healthcheck.0.command", "pg_isready -h localhost"),
+			},
+			{
+				name: "HTTPWithoutURL",
+				healthcheck: `
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`healthcheck type "http" requires url`),
+			},
+			{
+				name: "TCPWithoutAddress",
+				healthcheck: `
+					type = "tcp"
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`healthcheck type "tcp" requires address`),
+			},
+			{
+				name: "TCPWithStatuses",
+				healthcheck: `
+					type = "tcp"
+					address = "localhost:50051"
+					expected_statuses = [200]
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`healthcheck expected_statuses cannot be set when type is "tcp"`),
+			},
+			{
+				name: "CommandWithURL",
+				healthcheck: `
+					type = "command"
+					command = "true"
+					url = "http://localhost:13337/healthz"
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`healthcheck url cannot be set when type is "command"`),
+			},
+			{
+				name: "TimeoutExceedsInterval",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					interval = 5
+					timeout = 10
+					threshold = 3`,
+				expectError: regexp.MustCompile(`healthcheck timeout \(10\) must not exceed its interval \(5\)`),
+			},
+			{
+				name: "InvalidStatus",
+				healthcheck: `
+					url = "http://localhost:13337/healthz"
+					expected_statuses = [42]
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`expected healthcheck\.0\.expected_statuses\.0 to be in the range \(100 - 599\), got 42`),
+			},
+			{
+				name: "InvalidType",
+				healthcheck: `
+					type = "grpc"
+					url = "http://localhost:13337/healthz"
+					interval = 5
+					threshold = 3`,
+				expectError: regexp.MustCompile(`expected healthcheck\.0\.type to be one of \["http" "tcp" "command"\], got grpc`),
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				resource.Test(t, resource.TestCase{
+					ProviderFactories: coderFactory(),
+					IsUnitTest:        true,
+					Steps: []resource.TestStep{{
+						Config: `
+						provider "coder" {}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "coder_app" "code-server" {
+							agent_id = coder_agent.dev.id
+							slug = "code-server"
+							url = "http://localhost:13337"
+							healthcheck {
+								` + c.healthcheck + `
+							}
+						}`,
+						Check:       c.check,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+	})
+
+	t.Run("Deeplink", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name        string
+			deeplink    string
+			extra       string
+			check       resource.TestCheckFunc
+			expectError *regexp.Regexp
+		}{
+			{
+				name: "OK",
+				deeplink: `
+					scheme   = "vscode"
+					template    = "coder.coder-remote/open?owner={owner}&workspace={workspace}&agent={agent}&folder={folder}&url={access_url}&token={token}"
+					folder      = "/home/coder/project"
+					allow_token = true`,
+				check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.#", "1"),
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.0.scheme", "vscode"),
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.0.folder", "/home/coder/project"),
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.0.allow_token", "true"),
+					resource.TestCheckNoResourceAttr("coder_app.ide", "url"),
+				),
+			},
+			{
+				name: "TokenNotAllowed",
+				deeplink: `
+					scheme   = "vscode"
+					template = "coder.coder-remote/open?workspace={workspace}&token={token}"`,
+				expectError: regexp.MustCompile(`deeplink template contains {token}, which puts a session token in the URI;\s+set allow_token = true to allow it`),
+			},
+			{
+				name: "NoPlaceholders",
+				deeplink: `
+					scheme   = "jetbrains-gateway"
+					template = "connect#type=coder"`,
+				check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.0.template", "connect#type=coder"),
+					resource.TestCheckResourceAttr("coder_app.ide", "deeplink.0.allow_token", "false"),
+				),
+			},
+			{
+				name: "UnknownPlaceholder",
+				deeplink: `
+					scheme   = "vscode"
+					template = "coder.coder-remote/open?workspace={workspace_name}"`,
+				expectError: regexp.MustCompile(`unknown placeholder "{workspace_name}" in deeplink template, must be one\s+of`),
+			},
+			{
+				name: "UnbalancedBrace",
+				deeplink: `
+					scheme   = "vscode"
+					template = "coder.coder-remote/open?workspace={workspace"`,
+				expectError: regexp.MustCompile(`unbalanced brace in deeplink template`),
+			},
+			{
+				name: "EmptyTemplate",
+				deeplink: `
+					scheme   = "vscode"
+					template = ""`,
+				expectError: regexp.MustCompile(`deeplink template must not be empty`),
+			},
+			{
+				name: "InvalidScheme",
+				deeplink: `
+					scheme   = "VS Code"
+					template = "open"`,
+				expectError: regexp.MustCompile(`invalid deeplink scheme "VS Code"`),
+			},
+			{
+				name: "BlockedScheme",
+				deeplink: `
+					scheme   = "javascript"
+					template = "alert(1)"`,
+				expectError: regexp.MustCompile(`deeplink scheme "javascript" is not allowed`),
+			},
+			{
+				name: "URL",
+				deeplink: `
+					scheme   = "rdp"
+					template = "full%20address=s:localhost:3389"`,
+				extra:       `url = "http://localhost:3389"`,
+				expectError: regexp.MustCompile(`conflicts with url`),
+			},
+			{
+				name: "External",
+				deeplink: `
+					scheme   = "rdp"
+					template = "full%20address=s:localhost:3389"`,
+				extra:       `external = true`,
+				expectError: regexp.MustCompile(`conflicts with external`),
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				t.Parallel()
+
+				config := fmt.Sprintf(`
+				provider "coder" {
+				}
+				resource "coder_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+				}
+				resource "coder_app" "ide" {
+					agent_id = coder_agent.dev.id
+					slug = "ide"
+					%s
+					deeplink {
+						%s
+					}
+				}
+				`, c.extra, c.deeplink)
+
+				resource.Test(t, resource.TestCase{
+					ProviderFactories: coderFactory(),
+					IsUnitTest:        true,
+					Steps: []resource.TestStep{{
+						Config:      config,
+						Check:       c.check,
+						ExpectError: c.expectError,
+					}},
+				})
+			})
+		}
+	})
+
 	t.Run("ConflictsWith", func(t *testing.T) {
 		t.Parallel()
 
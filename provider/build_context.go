@@ -0,0 +1,314 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// BuildContext is the contract between coderd and the provider for a single
+// workspace build. It is loaded and validated once when the provider is
+// configured, and every data source and resource reads from it instead of
+// the process environment.
+//
+// The zero value describes a build run outside of Coder, e.g. a local
+// `terraform plan`, where data sources fall back to placeholder values.
+type BuildContext struct {
+	// BuildID is the ID of the workspace build. It is empty when Terraform is
+	// not being run by a Coder provisioner.
+	BuildID string
+	// Transition is the raw workspace transition ("start", "stop" or
+	// "delete"). Readers that need a value default it to "start".
+	Transition string
+
+	Workspace WorkspaceBuildContext
+	Template  TemplateBuildContext
+	Owner     OwnerBuildContext
+	Task      TaskBuildContext
+
+	// IsPrebuild is true if the workspace is an unclaimed prebuilt workspace.
+	IsPrebuild bool
+	// IsPrebuildClaim is true if the workspace is a prebuilt workspace which
+	// has just been claimed.
+	IsPrebuildClaim bool
+
+	// The maps below are keyed the same way as the environment variables
+	// that carry them, since names are hashed or encoded by coderd. Use the
+	// Set* methods to populate them by their natural key.
+	parameters         map[string]string
+	previousParameters map[string]string
+	secretEnvs         map[string]string
+	secretFiles        map[string]string
+	externalAuthTokens map[string]string
+	agentTokens        map[string]string
+	agentScripts       map[string]string
+}
+
+type WorkspaceBuildContext struct {
+	ID   string
+	Name string
+}
+
+type TemplateBuildContext struct {
+	ID      string
+	Name    string
+	Version string
+}
+
+type OwnerBuildContext struct {
+	ID              string
+	Name            string
+	FullName        string
+	Email           string
+	SSHPublicKey    string
+	SSHPrivateKey   string
+	Groups          []string
+	SessionToken    string
+	OIDCAccessToken string
+	LoginType       string
+	RBACRoles       []OwnerRBACRole
+}
+
+type OwnerRBACRole struct {
+	Name  string `json:"name" mapstructure:"name"`
+	OrgID string `json:"org_id" mapstructure:"org_id"`
+}
+
+type TaskBuildContext struct {
+	ID     string
+	Prompt string
+}
+
+// BuildContextSource loads the BuildContext for the current workspace build.
+// It is called once from the provider's ConfigureContextFunc.
+type BuildContextSource func() (*BuildContext, error)
+
+// EnvBuildContextSource loads the BuildContext from the environment variables
+// set by the Coder provisioner.
+func EnvBuildContextSource() (*BuildContext, error) {
+	return ParseBuildContextEnv(os.Environ())
+}
+
+// StaticBuildContextSource returns a source that always yields the given
+// BuildContext. This is primarily useful for tests, which can construct
+// providers with different contexts in parallel.
+func StaticBuildContextSource(bc *BuildContext) BuildContextSource {
+	return func() (*BuildContext, error) {
+		return bc, nil
+	}
+}
+
+// ParseBuildContextEnv parses a BuildContext from a list of environment
+// variables in "KEY=value" form, as returned by os.Environ.
+func ParseBuildContextEnv(environ []string) (*BuildContext, error) {
+	bc := &BuildContext{}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "CODER_WORKSPACE_BUILD_ID":
+			bc.BuildID = value
+		case "CODER_WORKSPACE_TRANSITION":
+			bc.Transition = value
+		case "CODER_WORKSPACE_ID":
+			bc.Workspace.ID = value
+		case "CODER_WORKSPACE_NAME":
+			bc.Workspace.Name = value
+		case "CODER_WORKSPACE_TEMPLATE_ID":
+			bc.Template.ID = value
+		case "CODER_WORKSPACE_TEMPLATE_NAME":
+			bc.Template.Name = value
+		case "CODER_WORKSPACE_TEMPLATE_VERSION":
+			bc.Template.Version = value
+		case "CODER_WORKSPACE_OWNER_ID":
+			bc.Owner.ID = value
+		case "CODER_WORKSPACE_OWNER":
+			bc.Owner.Name = value
+		case "CODER_WORKSPACE_OWNER_NAME":
+			bc.Owner.FullName = value
+		case "CODER_WORKSPACE_OWNER_EMAIL":
+			bc.Owner.Email = value
+		case "CODER_WORKSPACE_OWNER_SSH_PUBLIC_KEY":
+			bc.Owner.SSHPublicKey = value
+		case "CODER_WORKSPACE_OWNER_SSH_PRIVATE_KEY":
+			bc.Owner.SSHPrivateKey = value
+		case "CODER_WORKSPACE_OWNER_GROUPS":
+			if err := json.NewDecoder(strings.NewReader(value)).Decode(&bc.Owner.Groups); err != nil {
+				return nil, xerrors.Errorf("invalid user groups: %w", err)
+			}
+		case "CODER_WORKSPACE_OWNER_SESSION_TOKEN":
+			bc.Owner.SessionToken = value
+		case "CODER_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN":
+			bc.Owner.OIDCAccessToken = value
+		case "CODER_WORKSPACE_OWNER_LOGIN_TYPE":
+			bc.Owner.LoginType = value
+		case "CODER_WORKSPACE_OWNER_RBAC_ROLES":
+			if err := json.NewDecoder(strings.NewReader(value)).Decode(&bc.Owner.RBACRoles); err != nil {
+				return nil, xerrors.Errorf("invalid user rbac roles: %w", err)
+			}
+		case "CODER_TASK_ID":
+			bc.Task.ID = value
+		case "CODER_TASK_PROMPT":
+			bc.Task.Prompt = value
+		case IsPrebuildEnvironmentVariable():
+			bc.IsPrebuild = strings.EqualFold(value, "true")
+		case IsPrebuildClaimEnvironmentVariable():
+			bc.IsPrebuildClaim = strings.EqualFold(value, "true")
+		default:
+			bc.parseDynamicEnv(key, value)
+		}
+	}
+	return bc, nil
+}
+
+// parseDynamicEnv handles environment variables whose names embed a key,
+// such as a hashed parameter name or an external auth provider ID.
+func (b *BuildContext) parseDynamicEnv(key, value string) {
+	// Order matters: more specific prefixes must be checked first.
+	for _, p := range []struct {
+		prefix string
+		target *map[string]string
+	}{
+		{"CODER_PARAMETER_PREVIOUS_", &b.previousParameters},
+		{"CODER_PARAMETER_", &b.parameters},
+		{"CODER_SECRET_ENV_", &b.secretEnvs},
+		{"CODER_SECRET_FILE_", &b.secretFiles},
+		{"CODER_EXTERNAL_AUTH_ACCESS_TOKEN_", &b.externalAuthTokens},
+		{"CODER_RUNNING_WORKSPACE_AGENT_TOKEN_", &b.agentTokens},
+		{"CODER_AGENT_SCRIPT_", &b.agentScripts},
+	} {
+		suffix, ok := strings.CutPrefix(key, p.prefix)
+		if !ok {
+			continue
+		}
+		setKey(p.target, suffix, value)
+		return
+	}
+}
+
+// clone returns a deep copy of b, so that it can be modified without
+// affecting other providers configured from the same source.
+func (b *BuildContext) clone() *BuildContext {
+	c := *b
+	c.Owner.Groups = slices.Clone(b.Owner.Groups)
+	c.Owner.RBACRoles = slices.Clone(b.Owner.RBACRoles)
+	c.parameters = maps.Clone(b.parameters)
+	c.previousParameters = maps.Clone(b.previousParameters)
+	c.secretEnvs = maps.Clone(b.secretEnvs)
+	c.secretFiles = maps.Clone(b.secretFiles)
+	c.externalAuthTokens = maps.Clone(b.externalAuthTokens)
+	c.agentTokens = maps.Clone(b.agentTokens)
+	c.agentScripts = maps.Clone(b.agentScripts)
+	return &c
+}
+
+func setKey(m *map[string]string, key, value string) {
+	if *m == nil {
+		*m = map[string]string{}
+	}
+	(*m)[key] = value
+}
+
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetParameter sets the user-provided value of the named parameter.
+func (b *BuildContext) SetParameter(name, value string) {
+	setKey(&b.parameters, hashKey(name), value)
+}
+
+// SetPreviousParameter sets the value of the named parameter from the
+// previous workspace build.
+func (b *BuildContext) SetPreviousParameter(name, value string) {
+	setKey(&b.previousParameters, hashKey(name), value)
+}
+
+// SetSecretEnv sets the value of a user secret matched by environment
+// variable name.
+func (b *BuildContext) SetSecretEnv(envName, value string) {
+	setKey(&b.secretEnvs, envName, value)
+}
+
+// SetSecretFile sets the value of a user secret matched by file path.
+func (b *BuildContext) SetSecretFile(filePath, value string) {
+	setKey(&b.secretFiles, hex.EncodeToString([]byte(filePath)), value)
+}
+
+// SetExternalAuthAccessToken sets the access token for the external auth
+// provider with the given ID.
+func (b *BuildContext) SetExternalAuthAccessToken(id, token string) {
+	setKey(&b.externalAuthTokens, id, token)
+}
+
+// SetRunningAgentToken sets the token of a running agent that should be
+// reused when a prebuilt workspace is claimed.
+func (b *BuildContext) SetRunningAgentToken(agentID, token string) {
+	setKey(&b.agentTokens, hashKey(agentID), token)
+}
+
+// SetAgentScript sets the init script template for agents running on the
+// given operating system and architecture.
+func (b *BuildContext) SetAgentScript(operatingSystem, arch, script string) {
+	setKey(&b.agentScripts, agentScriptKey(operatingSystem, arch), script)
+}
+
+func (b *BuildContext) parameter(name string) (string, bool) {
+	v, ok := b.parameters[hashKey(name)]
+	return v, ok
+}
+
+func (b *BuildContext) previousParameter(name string) (string, bool) {
+	v, ok := b.previousParameters[hashKey(name)]
+	return v, ok
+}
+
+func (b *BuildContext) secretEnv(envName string) string {
+	return b.secretEnvs[envName]
+}
+
+func (b *BuildContext) secretFile(filePath string) string {
+	return b.secretFiles[hex.EncodeToString([]byte(filePath))]
+}
+
+func (b *BuildContext) externalAuthAccessToken(id string) string {
+	return b.externalAuthTokens[id]
+}
+
+func (b *BuildContext) runningAgentToken(agentID string) string {
+	return b.agentTokens[hashKey(agentID)]
+}
+
+func (b *BuildContext) agentScript(operatingSystem, arch string) string {
+	return b.agentScripts[agentScriptKey(operatingSystem, arch)]
+}
+
+// requireTemplateField returns an error if value is empty during a workspace
+// build. Outside of a build, the value is returned as-is.
+func (b *BuildContext) requireTemplateField(name, value string) (string, error) {
+	if b.BuildID != "" && value == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return value, nil
+}
+
+func agentScriptKey(operatingSystem, arch string) string {
+	return fmt.Sprintf("%s_%s", operatingSystem, arch)
+}
+
+// agentScriptEnvironmentVariable returns the environment variable that
+// carries the init script for agents on the given platform.
+func agentScriptEnvironmentVariable(operatingSystem, arch string) string {
+	return "CODER_AGENT_SCRIPT_" + agentScriptKey(operatingSystem, arch)
+}
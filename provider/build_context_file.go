@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"encoding/json"
+	"os"
+
+	"golang.org/x/xerrors"
+)
+
+// BuildContextFileVersion is the version of the build context file format
+// understood by this provider.
+const BuildContextFileVersion = 1
+
+// BuildContextFileEnvironmentVariable returns the name of the environment
+// variable that points to a build context file.
+//
+// A build context file is a JSON document carrying the same data as the
+// individual CODER_* environment variables. Passing it as a file keeps secrets
+// out of the process environment and avoids environment size limits for
+// templates with many parameters.
+func BuildContextFileEnvironmentVariable() string {
+	return "CODER_BUILD_CONTEXT_FILE"
+}
+
+// buildContextFile is the on-disk representation of a BuildContext. Every
+// field is optional: values present in the file take precedence over the
+// corresponding environment variables, and absent values fall back to them.
+type buildContextFile struct {
+	Version         int                       `json:"version"`
+	BuildID         string                    `json:"build_id"`
+	Transition      string                    `json:"transition"`
+	Workspace       buildContextFileWorkspace `json:"workspace"`
+	Template        buildContextFileTemplate  `json:"template"`
+	Owner           buildContextFileOwner     `json:"owner"`
+	Task            buildContextFileTask      `json:"task"`
+	IsPrebuild      *bool                     `json:"is_prebuild"`
+	IsPrebuildClaim *bool                     `json:"is_prebuild_claim"`
+
+	Parameters         map[string]string `json:"parameters"`
+	PreviousParameters map[string]string `json:"previous_parameters"`
+	Secrets            struct {
+		Env   map[string]string `json:"env"`
+		Files map[string]string `json:"files"`
+	} `json:"secrets"`
+	ExternalAuthAccessTokens map[string]string `json:"external_auth_access_tokens"`
+	RunningAgentTokens       map[string]string `json:"running_agent_tokens"`
+	// AgentScripts is keyed by operating system, then architecture.
+	AgentScripts map[string]map[string]string `json:"agent_scripts"`
+}
+
+type buildContextFileWorkspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type buildContextFileTemplate struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type buildContextFileOwner struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	FullName        string          `json:"full_name"`
+	Email           string          `json:"email"`
+	SSHPublicKey    string          `json:"ssh_public_key"`
+	SSHPrivateKey   string          `json:"ssh_private_key"`
+	Groups          []string        `json:"groups"`
+	SessionToken    string          `json:"session_token"`
+	OIDCAccessToken string          `json:"oidc_access_token"`
+	LoginType       string          `json:"login_type"`
+	RBACRoles       []OwnerRBACRole `json:"rbac_roles"`
+}
+
+type buildContextFileTask struct {
+	ID     string `json:"id"`
+	Prompt string `json:"prompt"`
+}
+
+// LoadFile overlays the contents of the build context file at path onto b.
+// Values present in the file replace the ones already in b.
+func (b *BuildContext) LoadFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return xerrors.Errorf("read build context file: %w", err)
+	}
+	var f buildContextFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return xerrors.Errorf("decode build context file %q: %w", path, err)
+	}
+	if f.Version != BuildContextFileVersion {
+		return xerrors.Errorf("unsupported build context file version %d, expected %d", f.Version, BuildContextFileVersion)
+	}
+
+	overlay(&b.BuildID, f.BuildID)
+	overlay(&b.Transition, f.Transition)
+	overlay(&b.Workspace.ID, f.Workspace.ID)
+	overlay(&b.Workspace.Name, f.Workspace.Name)
+	overlay(&b.Template.ID, f.Template.ID)
+	overlay(&b.Template.Name, f.Template.Name)
+	overlay(&b.Template.Version, f.Template.Version)
+	overlay(&b.Owner.ID, f.Owner.ID)
+	overlay(&b.Owner.Name, f.Owner.Name)
+	overlay(&b.Owner.FullName, f.Owner.FullName)
+	overlay(&b.Owner.Email, f.Owner.Email)
+	overlay(&b.Owner.SSHPublicKey, f.Owner.SSHPublicKey)
+	overlay(&b.Owner.SSHPrivateKey, f.Owner.SSHPrivateKey)
+	overlay(&b.Owner.SessionToken, f.Owner.SessionToken)
+	overlay(&b.Owner.OIDCAccessToken, f.Owner.OIDCAccessToken)
+	overlay(&b.Owner.LoginType, f.Owner.LoginType)
+	overlay(&b.Task.ID, f.Task.ID)
+	overlay(&b.Task.Prompt, f.Task.Prompt)
+	if f.Owner.Groups != nil {
+		b.Owner.Groups = f.Owner.Groups
+	}
+	if f.Owner.RBACRoles != nil {
+		b.Owner.RBACRoles = f.Owner.RBACRoles
+	}
+	if f.IsPrebuild != nil {
+		b.IsPrebuild = *f.IsPrebuild
+	}
+	if f.IsPrebuildClaim != nil {
+		b.IsPrebuildClaim = *f.IsPrebuildClaim
+	}
+
+	for name, value := range f.Parameters {
+		b.SetParameter(name, value)
+	}
+	for name, value := range f.PreviousParameters {
+		b.SetPreviousParameter(name, value)
+	}
+	for envName, value := range f.Secrets.Env {
+		b.SetSecretEnv(envName, value)
+	}
+	for filePath, value := range f.Secrets.Files {
+		b.SetSecretFile(filePath, value)
+	}
+	for id, token := range f.ExternalAuthAccessTokens {
+		b.SetExternalAuthAccessToken(id, token)
+	}
+	for agentID, token := range f.RunningAgentTokens {
+		b.SetRunningAgentToken(agentID, token)
+	}
+	for operatingSystem, scripts := range f.AgentScripts {
+		for arch, script := range scripts {
+			b.SetAgentScript(operatingSystem, arch, script)
+		}
+	}
+	return nil
+}
+
+func overlay(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
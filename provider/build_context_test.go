@@ -0,0 +1,273 @@
+package provider_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestParseBuildContextEnv(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		bc, err := provider.ParseBuildContextEnv([]string{
+			"CODER_WORKSPACE_BUILD_ID=build-id",
+			"CODER_WORKSPACE_TRANSITION=stop",
+			"CODER_WORKSPACE_ID=workspace-id",
+			"CODER_WORKSPACE_NAME=dev",
+			"CODER_WORKSPACE_TEMPLATE_NAME=docker",
+			"CODER_WORKSPACE_OWNER=owner123",
+			"CODER_WORKSPACE_OWNER_GROUPS=[\"group1\",\"group2\"]",
+			`CODER_WORKSPACE_OWNER_RBAC_ROLES=[{"name":"member","org_id":"org"}]`,
+			"CODER_TASK_PROMPT=a=b",
+			provider.IsPrebuildEnvironmentVariable() + "=TRUE",
+			"UNRELATED",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "build-id", bc.BuildID)
+		assert.Equal(t, "stop", bc.Transition)
+		assert.Equal(t, "workspace-id", bc.Workspace.ID)
+		assert.Equal(t, "dev", bc.Workspace.Name)
+		assert.Equal(t, "docker", bc.Template.Name)
+		assert.Equal(t, "owner123", bc.Owner.Name)
+		assert.Equal(t, []string{"group1", "group2"}, bc.Owner.Groups)
+		assert.Equal(t, []provider.OwnerRBACRole{{Name: "member", OrgID: "org"}}, bc.Owner.RBACRoles)
+		assert.Equal(t, "a=b", bc.Task.Prompt)
+		assert.True(t, bc.IsPrebuild)
+		assert.False(t, bc.IsPrebuildClaim)
+	})
+
+	t.Run("InvalidGroups", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := provider.ParseBuildContextEnv([]string{"CODER_WORKSPACE_OWNER_GROUPS=group1"})
+		require.ErrorContains(t, err, "invalid user groups")
+	})
+
+	t.Run("InvalidRBACRoles", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := provider.ParseBuildContextEnv([]string{"CODER_WORKSPACE_OWNER_RBAC_ROLES={}"})
+		require.ErrorContains(t, err, "invalid user rbac roles")
+	})
+}
+
+// TestBuildContextSource ensures that providers constructed with different
+// build contexts can run in parallel without touching the environment.
+func TestBuildContextSource(t *testing.T) {
+	t.Parallel()
+
+	for _, owner := range []string{"alice", "bob"} {
+		t.Run(owner, func(t *testing.T) {
+			t.Parallel()
+
+			bc := &provider.BuildContext{
+				BuildID:    "build-" + owner,
+				Transition: "stop",
+				Workspace: provider.WorkspaceBuildContext{
+					Name: owner + "-workspace",
+				},
+				Template: provider.TemplateBuildContext{
+					ID:      "template-id",
+					Name:    "template",
+					Version: "v1",
+				},
+				Owner: provider.OwnerBuildContext{
+					Name:   owner,
+					Email:  owner + "@example.com",
+					Groups: []string{owner + "-group"},
+				},
+			}
+			bc.SetParameter("region", owner+"-region")
+			bc.SetSecretEnv("TOKEN", owner+"-token")
+			bc.SetSecretFile("~/.ssh/id_rsa", owner+"-key")
+			bc.SetExternalAuthAccessToken("github", owner+"-github")
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactoryWithBuildContext(bc),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: `
+					provider "coder" {}
+					data "coder_workspace" "me" {}
+					data "coder_workspace_owner" "me" {}
+					data "coder_parameter" "region" {
+						name = "region"
+					}
+					data "coder_secret" "token" {
+						env          = "TOKEN"
+						help_message = "Add a token"
+					}
+					data "coder_secret" "key" {
+						file         = "~/.ssh/id_rsa"
+						help_message = "Add a key"
+					}
+					data "coder_external_auth" "github" {
+						id = "github"
+					}
+					`,
+					Check: func(state *terraform.State) error {
+						require.Len(t, state.Modules, 1)
+						resources := state.Modules[0].Resources
+						attr := func(name, key string) string {
+							res := resources[name]
+							require.NotNil(t, res, name)
+							return res.Primary.Attributes[key]
+						}
+
+						assert.Equal(t, "stop", attr("data.coder_workspace.me", "transition"))
+						assert.Equal(t, "0", attr("data.coder_workspace.me", "start_count"))
+						assert.Equal(t, owner+"-workspace", attr("data.coder_workspace.me", "name"))
+						assert.Equal(t, owner, attr("data.coder_workspace_owner.me", "name"))
+						assert.Equal(t, fmt.Sprintf("%s@example.com", owner), attr("data.coder_workspace_owner.me", "email"))
+						assert.Equal(t, owner+"-group", attr("data.coder_workspace_owner.me", "groups.0"))
+						assert.Equal(t, owner+"-region", attr("data.coder_parameter.region", "value"))
+						assert.Equal(t, owner+"-token", attr("data.coder_secret.token", "value"))
+						assert.Equal(t, owner+"-key", attr("data.coder_secret.key", "value"))
+						assert.Equal(t, owner+"-github", attr("data.coder_external_auth.github", "access_token"))
+						return nil
+					},
+				}},
+			})
+		})
+	}
+}
+
+func TestBuildContextFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		// Values from the environment act as a fallback for anything the
+		// file does not set.
+		bc := &provider.BuildContext{
+			Workspace: provider.WorkspaceBuildContext{Name: "from-env"},
+			Owner:     provider.OwnerBuildContext{Name: "env-owner"},
+		}
+		path := writeBuildContextFile(t, `{
+			"version": 1,
+			"transition": "stop",
+			"owner": {
+				"name": "file-owner",
+				"groups": ["group1"],
+				"rbac_roles": [{"name": "member", "org_id": "org"}]
+			},
+			"is_prebuild": true,
+			"parameters": {"region": "us-east-1"},
+			"secrets": {
+				"env": {"TOKEN": "token-value"},
+				"files": {"~/.ssh/id_rsa": "key-value"}
+			},
+			"external_auth_access_tokens": {"github": "github-token"}
+		}`)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(bc),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: fmt.Sprintf(`
+				provider "coder" {
+					build_context_file = %q
+				}
+				data "coder_workspace" "me" {}
+				data "coder_workspace_owner" "me" {}
+				data "coder_parameter" "region" {
+					name = "region"
+				}
+				data "coder_secret" "token" {
+					env          = "TOKEN"
+					help_message = "Add a token"
+				}
+				data "coder_secret" "key" {
+					file         = "~/.ssh/id_rsa"
+					help_message = "Add a key"
+				}
+				data "coder_external_auth" "github" {
+					id = "github"
+				}
+				`, path),
+				Check: func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					resources := state.Modules[0].Resources
+					attr := func(name, key string) string {
+						res := resources[name]
+						require.NotNil(t, res, name)
+						return res.Primary.Attributes[key]
+					}
+
+					assert.Equal(t, "from-env", attr("data.coder_workspace.me", "name"))
+					assert.Equal(t, "stop", attr("data.coder_workspace.me", "transition"))
+					assert.Equal(t, "true", attr("data.coder_workspace.me", "is_prebuild"))
+					assert.Equal(t, "file-owner", attr("data.coder_workspace_owner.me", "name"))
+					assert.Equal(t, "group1", attr("data.coder_workspace_owner.me", "groups.0"))
+					assert.Equal(t, "member", attr("data.coder_workspace_owner.me", "rbac_roles.0.name"))
+					assert.Equal(t, "us-east-1", attr("data.coder_parameter.region", "value"))
+					assert.Equal(t, "token-value", attr("data.coder_secret.token", "value"))
+					assert.Equal(t, "key-value", attr("data.coder_secret.key", "value"))
+					assert.Equal(t, "github-token", attr("data.coder_external_auth.github", "access_token"))
+					return nil
+				},
+			}},
+		})
+		// The source's context must not be modified by the file.
+		assert.Equal(t, "env-owner", bc.Owner.Name)
+	})
+
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeBuildContextFile(t, `{"version": 2}`)
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(&provider.BuildContext{}),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: fmt.Sprintf(`
+				provider "coder" {
+					build_context_file = %q
+				}
+				data "coder_workspace" "me" {}
+				`, path),
+				ExpectError: regexp.MustCompile(`unsupported build context file version 2`),
+			}},
+		})
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(&provider.BuildContext{}),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: fmt.Sprintf(`
+				provider "coder" {
+					build_context_file = %q
+				}
+				data "coder_workspace" "me" {}
+				`, filepath.Join(t.TempDir(), "missing.json")),
+				ExpectError: regexp.MustCompile(`read build context file`),
+			}},
+		})
+	})
+}
+
+func writeBuildContextFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "build-context.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func devcontainerResource() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		SchemaVersion: 1,
 
 		Description: "Define a Dev Container the agent should know of and attempt to autostart.\n\n-> This resource is only available in Coder v2.21 and later.",
@@ -52,4 +52,6 @@ func devcontainerResource() *schema.Resource {
 			},
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"subagent_id", "agent_id", "workspace_folder"}, "config_path")
+	return resource
 }
@@ -11,7 +11,7 @@ import (
 )
 
 func envResource() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		SchemaVersion: 1,
 
 		Description: "Use this resource to set an environment variable in a workspace. Note that this resource cannot be used to overwrite existing environment variables set on the `coder_agent` resource.",
@@ -20,7 +20,10 @@ func envResource() *schema.Resource {
 
 			return nil
 		},
-		ReadContext:   schema.NoopContext,
+		ReadContext: schema.NoopContext,
+		// Only agent_id and name force a new environment variable, the value
+		// and merge strategy are updated in place.
+		UpdateContext: schema.NoopContext,
 		DeleteContext: schema.NoopContext,
 		Schema: map[string]*schema.Schema{
 			"agent_id": {
@@ -42,13 +45,11 @@ func envResource() *schema.Resource {
 			"value": {
 				Type:        schema.TypeString,
 				Description: "The value of the environment variable.",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"merge_strategy": {
 				Type:        schema.TypeString,
 				Description: "Controls how this environment variable is merged when multiple coder_env resources define the same name. `replace` (default): last value wins. `append`: appends to existing value with a colon `:` separator. `prepend`: prepends to existing value with a colon `:` separator. `error`: fail the build if another coder_env defines the same name. When multiple resources append or prepend to the same name, they are applied in alphabetical order by Terraform resource address.",
-				ForceNew:    true,
 				Optional:    true,
 				Default:     "replace",
 				ValidateFunc: validation.StringInSlice([]string{
@@ -57,4 +58,6 @@ func envResource() *schema.Resource {
 			},
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"agent_id", "name"})
+	return resource
 }
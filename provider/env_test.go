@@ -1,6 +1,7 @@
 package provider_test
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -45,6 +46,43 @@ func TestEnv(t *testing.T) {
 	})
 }
 
+func TestEnvUpdate(t *testing.T) {
+	t.Parallel()
+
+	envConfig := func(name, value, mergeStrategy string) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+			}
+			resource "coder_env" "example" {
+				agent_id = "king"
+				name = %q
+				value = %q
+				merge_strategy = %q
+			}
+			`, name, value, mergeStrategy)
+	}
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactory(),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: envConfig("PATH", "/opt/bin", "replace"),
+			Check:  checkResourceID(t, "coder_env.example", &id, true),
+		}, {
+			Config: envConfig("PATH", "/usr/local/bin", "prepend"),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_env.example", &id, true),
+				resource.TestCheckResourceAttr("coder_env.example", "value", "/usr/local/bin"),
+				resource.TestCheckResourceAttr("coder_env.example", "merge_strategy", "prepend"),
+			),
+		}, {
+			Config: envConfig("GOPATH", "/usr/local/bin", "prepend"),
+			Check:  checkResourceID(t, "coder_env.example", &id, false),
+		}},
+	})
+}
+
 func TestEnvEmptyValue(t *testing.T) {
 	t.Parallel()
 
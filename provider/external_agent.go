@@ -9,7 +9,7 @@ import (
 )
 
 func externalAgentResource() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		SchemaVersion: 1,
 
 		Description: "Define an external agent to be used in a workspace.\n\n~> **Warning:** External agents require a [Premium](https://coder.com/pricing) Coder license.",
@@ -28,4 +28,6 @@ func externalAgentResource() *schema.Resource {
 			},
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"agent_id"})
+	return resource
 }
@@ -6,8 +6,6 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
 
 // externalAuthDataSource returns a schema for an external authentication data source.
@@ -23,7 +21,11 @@ func externalAuthDataSource() *schema.Resource {
 			}
 			rd.SetId(id)
 
-			accessToken := helpers.OptionalEnv(ExternalAuthAccessTokenEnvironmentVariable(id))
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
+			accessToken := bc.externalAuthAccessToken(id)
 			rd.Set("access_token", accessToken)
 			return nil
 		},
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ ephemeral.EphemeralResourceWithConfigure = &externalAuthEphemeralResource{}
+
+func newExternalAuthEphemeralResource() ephemeral.EphemeralResource {
+	return &externalAuthEphemeralResource{}
+}
+
+// externalAuthEphemeralResource is the ephemeral counterpart of the
+// coder_external_auth data source.
+type externalAuthEphemeralResource struct {
+	buildContext *BuildContext
+}
+
+type externalAuthEphemeralResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	AccessToken types.String `tfsdk:"access_token"`
+}
+
+func (*externalAuthEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_external_auth"
+}
+
+func (*externalAuthEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this ephemeral resource to read an external auth access token without persisting it to " +
+			"the Terraform plan or state. Users are only prompted to authenticate for providers declared with " +
+			"the `coder_external_auth` data source, so declare the provider there as well. Requires Terraform " +
+			"v1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The ID of a configured external auth provider set up in your Coder deployment.",
+				Required:    true,
+			},
+			"access_token": schema.StringAttribute{
+				Description: "The access token returned by the external auth provider. This can be used to pre-authenticate command-line tools.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *externalAuthEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.buildContext = ephemeralBuildContext(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *externalAuthEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var model externalAuthEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	model.AccessToken = types.StringValue(r.buildContext.externalAuthAccessToken(model.ID.ValueString()))
+	resp.Diagnostics.Append(resp.Result.Set(ctx, model)...)
+}
@@ -0,0 +1,30 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestExternalAuthEphemeral(t *testing.T) {
+	t.Parallel()
+
+	bc := &provider.BuildContext{}
+	bc.SetExternalAuthAccessToken("github", "gh-token")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: coderProtoV6Factory(bc),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: `
+			ephemeral "coder_external_auth" "github" {
+				id = "github"
+			}
+			ephemeral "coder_external_auth" "gitlab" {
+				id = "gitlab"
+			}` + ephemeralCheck(`ephemeral.coder_external_auth.github.access_token == "gh-token" && ephemeral.coder_external_auth.gitlab.access_token == ""`),
+		}},
+	})
+}
@@ -0,0 +1,319 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	fwprovider "github.com/hashicorp/terraform-plugin-framework/provider"
+	fwschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-mux/tf5to6server"
+	"github.com/hashicorp/terraform-plugin-mux/tf6muxserver"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// NewMuxServer returns a protocol version 6 provider server that serves the
+// SDKv2 provider alongside the plugin-framework provider. Both load their
+// BuildContext from source.
+//
+// Each resource, data source and function must be served by exactly one of
+// the two providers. New resources should be written in the plugin framework;
+// see the "Provider architecture" section of the README for how to migrate
+// existing SDKv2 resources.
+func NewMuxServer(ctx context.Context, source BuildContextSource) (tfprotov6.ProviderServer, error) {
+	sdkServer, err := tf5to6server.UpgradeServer(ctx, NewWithBuildContextSource(source).GRPCProvider)
+	if err != nil {
+		return nil, err
+	}
+	servers := []func() tfprotov6.ProviderServer{
+		// The mux server returns the provider schema of the last server, so
+		// the SDKv2 provider goes last to keep its MaxItems constraints.
+		providerserver.NewProtocol6(NewFrameworkProvider(source)),
+		func() tfprotov6.ProviderServer { return sdkServer },
+	}
+	muxServer, err := tf6muxserver.NewMuxServer(ctx, servers...)
+	if err != nil {
+		return nil, err
+	}
+	return muxServer.ProviderServer(), nil
+}
+
+// NewFrameworkProvider returns the plugin-framework half of the provider. It
+// serves provider-defined functions, ephemeral resources and any resource or
+// data source written in the plugin framework; everything else is served by
+// the SDKv2 provider returned from NewWithBuildContextSource.
+func NewFrameworkProvider(source BuildContextSource) fwprovider.Provider {
+	return &frameworkProvider{source: source}
+}
+
+type frameworkProvider struct {
+	source BuildContextSource
+}
+
+var (
+	_ fwprovider.ProviderWithFunctions          = &frameworkProvider{}
+	_ fwprovider.ProviderWithEphemeralResources = &frameworkProvider{}
+)
+
+func (*frameworkProvider) Metadata(_ context.Context, _ fwprovider.MetadataRequest, resp *fwprovider.MetadataResponse) {
+	resp.TypeName = "coder"
+}
+
+// Schema must be identical to the SDKv2 provider schema, since Terraform sees
+// a single provider. Validation and defaults are left to the SDKv2 provider,
+// which is the one that reads the configuration.
+func (*frameworkProvider) Schema(_ context.Context, _ fwprovider.SchemaRequest, resp *fwprovider.SchemaResponse) {
+	sdkSchema := NewWithBuildContextSource(nil).Schema
+	simulate := sdkSchema["simulate"].Elem.(*schema.Resource).Schema
+	workspace := simulate["workspace"].Elem.(*schema.Resource).Schema
+	owner := simulate["owner"].Elem.(*schema.Resource).Schema
+	rbacRoles := owner["rbac_roles"].Elem.(*schema.Resource).Schema
+
+	resp.Schema = fwschema.Schema{
+		Attributes: map[string]fwschema.Attribute{
+			"url": fwschema.StringAttribute{
+				Description: sdkSchema["url"].Description,
+				Optional:    true,
+			},
+			"build_context_file": fwschema.StringAttribute{
+				Description: sdkSchema["build_context_file"].Description,
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]fwschema.Block{
+			"simulate": fwschema.ListNestedBlock{
+				Description: sdkSchema["simulate"].Description,
+				NestedObject: fwschema.NestedBlockObject{
+					Attributes: map[string]fwschema.Attribute{
+						"parameters": fwschema.MapAttribute{
+							Description: simulate["parameters"].Description,
+							ElementType: types.StringType,
+							Optional:    true,
+						},
+						"secrets": fwschema.MapAttribute{
+							Description: simulate["secrets"].Description,
+							ElementType: types.StringType,
+							Optional:    true,
+							Sensitive:   true,
+						},
+						"external_auth": fwschema.MapAttribute{
+							Description: simulate["external_auth"].Description,
+							ElementType: types.StringType,
+							Optional:    true,
+							Sensitive:   true,
+						},
+						"transition": fwschema.StringAttribute{
+							Description: simulate["transition"].Description,
+							Optional:    true,
+						},
+						"prebuild": fwschema.BoolAttribute{
+							Description: simulate["prebuild"].Description,
+							Optional:    true,
+						},
+						"prebuild_claim": fwschema.BoolAttribute{
+							Description: simulate["prebuild_claim"].Description,
+							Optional:    true,
+						},
+					},
+					Blocks: map[string]fwschema.Block{
+						"workspace": fwschema.ListNestedBlock{
+							Description: simulate["workspace"].Description,
+							NestedObject: fwschema.NestedBlockObject{
+								Attributes: map[string]fwschema.Attribute{
+									"id": fwschema.StringAttribute{
+										Description: workspace["id"].Description,
+										Optional:    true,
+									},
+									"name": fwschema.StringAttribute{
+										Description: workspace["name"].Description,
+										Optional:    true,
+									},
+								},
+							},
+						},
+						"owner": fwschema.ListNestedBlock{
+							Description: simulate["owner"].Description,
+							NestedObject: fwschema.NestedBlockObject{
+								Attributes: map[string]fwschema.Attribute{
+									"id": fwschema.StringAttribute{
+										Description: owner["id"].Description,
+										Optional:    true,
+									},
+									"name": fwschema.StringAttribute{
+										Description: owner["name"].Description,
+										Optional:    true,
+									},
+									"full_name": fwschema.StringAttribute{
+										Description: owner["full_name"].Description,
+										Optional:    true,
+									},
+									"email": fwschema.StringAttribute{
+										Description: owner["email"].Description,
+										Optional:    true,
+									},
+									"groups": fwschema.ListAttribute{
+										Description: owner["groups"].Description,
+										ElementType: types.StringType,
+										Optional:    true,
+									},
+									"login_type": fwschema.StringAttribute{
+										Description: owner["login_type"].Description,
+										Optional:    true,
+									},
+									"ssh_public_key": fwschema.StringAttribute{
+										Description: owner["ssh_public_key"].Description,
+										Optional:    true,
+									},
+								},
+								Blocks: map[string]fwschema.Block{
+									"rbac_roles": fwschema.ListNestedBlock{
+										Description: owner["rbac_roles"].Description,
+										NestedObject: fwschema.NestedBlockObject{
+											Attributes: map[string]fwschema.Attribute{
+												"name": fwschema.StringAttribute{
+													Description: rbacRoles["name"].Description,
+													Required:    true,
+												},
+												"org_id": fwschema.StringAttribute{
+													Description: rbacRoles["org_id"].Description,
+													Optional:    true,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure loads the BuildContext for plugin-framework ephemeral resources,
+// and starts recording the coder_app_group names of this operation. The
+// configuration is validated by the SDKv2 provider, so only the arguments
+// that affect the BuildContext are read here.
+func (p *frameworkProvider) Configure(_ context.Context, req fwprovider.ConfigureRequest, resp *fwprovider.ConfigureResponse) {
+	resp.ResourceData = &appGroupNames{}
+	raw, err := tftypesToInterface(req.Config.Raw)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid provider configuration", err.Error())
+		return
+	}
+	cfg, _ := raw.(map[string]interface{})
+	buildContextFile, _ := cfg["build_context_file"].(string)
+	if buildContextFile == "" {
+		buildContextFile = os.Getenv(BuildContextFileEnvironmentVariable())
+	}
+	rawSimulate, _ := cfg["simulate"].([]interface{})
+
+	// Warnings are dropped, since the SDKv2 provider already reports them.
+	buildContext, _, err := configureBuildContext(p.source, buildContextFile, rawSimulate)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to load build context", err.Error())
+		return
+	}
+	resp.EphemeralResourceData = buildContext
+}
+
+func (*frameworkProvider) DataSources(context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newAgentInitDataSource,
+	}
+}
+
+func (*frameworkProvider) Resources(context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newAppGroupResource,
+		newPortResource,
+	}
+}
+
+func (*frameworkProvider) EphemeralResources(context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		newSecretEphemeralResource,
+		newExternalAuthEphemeralResource,
+		newOwnerCredentialsEphemeralResource,
+	}
+}
+
+func (p *frameworkProvider) Functions(context.Context) []func() function.Function {
+	return []func() function.Function{
+		newParameterEnvNameFunction,
+		newSecretEnvNameFunction,
+		newSecretFileEnvNameFunction,
+		newRunningAgentTokenEnvNameFunction,
+		newValidateCronFunction,
+		newParseListStringFunction,
+		func() function.Function { return &appURLFunction{source: p.source} },
+	}
+}
+
+// tftypesToInterface converts a configuration value into the same shape that
+// SDKv2 uses for ResourceData, so that it can be decoded with mapstructure.
+// Null and unknown values become nil.
+func tftypesToInterface(v tftypes.Value) (interface{}, error) {
+	if v.IsNull() || !v.IsKnown() {
+		return nil, nil
+	}
+	switch typ := v.Type(); {
+	case typ.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		return s, err
+	case typ.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		return b, err
+	case typ.Is(tftypes.Number):
+		var f big.Float
+		if err := v.As(&f); err != nil {
+			return nil, err
+		}
+		n, _ := f.Float64()
+		return n, nil
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}), typ.Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+		if err := v.As(&elems); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, 0, len(elems))
+		for _, elem := range elems {
+			converted, err := tftypesToInterface(elem)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, converted)
+		}
+		return out, nil
+	case typ.Is(tftypes.Map{}), typ.Is(tftypes.Object{}):
+		var attrs map[string]tftypes.Value
+		if err := v.As(&attrs); err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, len(attrs))
+		for name, attr := range attrs {
+			converted, err := tftypesToInterface(attr)
+			if err != nil {
+				return nil, err
+			}
+			if converted != nil {
+				out[name] = converted
+			}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+}
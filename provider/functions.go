@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// envNameFunction exposes one of the *EnvironmentVariable helpers as a
+// provider-defined function, so templates and tooling can compute the
+// environment variable Coder uses to pass a value to the provider.
+type envNameFunction struct {
+	name        string
+	summary     string
+	description string
+	parameter   function.StringParameter
+	envName     func(string) string
+}
+
+func newParameterEnvNameFunction() function.Function {
+	return &envNameFunction{
+		name:        "parameter_env_name",
+		summary:     "Returns the environment variable used to pass a parameter value.",
+		description: "Returns the environment variable Coder uses to pass the value of the named `coder_parameter` to the provider during workspace builds.",
+		parameter: function.StringParameter{
+			Name:        "name",
+			Description: "The name of the parameter.",
+		},
+		envName: ParameterEnvironmentVariable,
+	}
+}
+
+func newSecretEnvNameFunction() function.Function {
+	return &envNameFunction{
+		name:        "secret_env_name",
+		summary:     "Returns the environment variable used to pass a secret matched by env name.",
+		description: "Returns the environment variable Coder uses to pass the value of a `coder_secret` with the given `env` to the provider during workspace builds.",
+		parameter: function.StringParameter{
+			Name:        "env",
+			Description: "The `env` of the secret.",
+		},
+		envName: SecretEnvEnvironmentVariable,
+	}
+}
+
+func newSecretFileEnvNameFunction() function.Function {
+	return &envNameFunction{
+		name:        "secret_file_env_name",
+		summary:     "Returns the environment variable used to pass a secret matched by file path.",
+		description: "Returns the environment variable Coder uses to pass the value of a `coder_secret` with the given `file` to the provider during workspace builds.",
+		parameter: function.StringParameter{
+			Name:        "file",
+			Description: "The `file` of the secret.",
+		},
+		envName: SecretFileEnvironmentVariable,
+	}
+}
+
+func newRunningAgentTokenEnvNameFunction() function.Function {
+	return &envNameFunction{
+		name:        "running_agent_token_env_name",
+		summary:     "Returns the environment variable used to pass a running agent's token.",
+		description: "Returns the environment variable Coder uses to pass the token of a running agent, which is reused when a prebuilt workspace is claimed.",
+		parameter: function.StringParameter{
+			Name:        "agent_id",
+			Description: "The `id` of the `coder_agent`, or an empty string for the token used by every agent without a token of its own.",
+		},
+		envName: RunningAgentTokenEnvironmentVariable,
+	}
+}
+
+func (f *envNameFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = f.name
+}
+
+func (f *envNameFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             f.summary,
+		MarkdownDescription: f.description,
+		Parameters:          []function.Parameter{f.parameter},
+		Return:              function.StringReturn{},
+	}
+}
+
+func (f *envNameFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, f.envName(value))
+}
+
+type validateCronFunction struct{}
+
+func newValidateCronFunction() function.Function {
+	return &validateCronFunction{}
+}
+
+func (*validateCronFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "validate_cron"
+}
+
+func (*validateCronFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Checks whether a string is a valid `coder_script` cron expression.",
+		MarkdownDescription: "Returns `true` if the given string is a valid cron expression for the `cron` " +
+			"attribute of `coder_script`. Note that Coder uses the 6-field format " +
+			"(seconds minutes hours day month day-of-week), so a 5-field Unix expression is valid but " +
+			"its first field is interpreted as seconds.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "expression",
+				Description: "The cron expression to validate.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (*validateCronFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var expression string
+	resp.Error = req.Arguments.Get(ctx, &expression)
+	if resp.Error != nil {
+		return
+	}
+	_, errs := ValidateCronExpression(expression)
+	resp.Error = resp.Result.Set(ctx, len(errs) == 0)
+}
+
+type parseListStringFunction struct{}
+
+func newParseListStringFunction() function.Function {
+	return &parseListStringFunction{}
+}
+
+func (*parseListStringFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_list_string"
+}
+
+func (*parseListStringFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Parses the value of a `list(string)` parameter.",
+		MarkdownDescription: "Parses the value of a `coder_parameter` of type `list(string)`, which is a " +
+			"JSON-encoded array of strings, into a list. Fails if the value is not a valid list of strings.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "value",
+				Description: "The parameter value.",
+			},
+		},
+		Return: function.ListReturn{ElementType: types.StringType},
+	}
+}
+
+func (*parseListStringFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+	items, err := valueIsListString(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, items)
+}
+
+// appURLFunction builds the path-based URL of a coder_app. Functions do not
+// have access to the provider configuration, so the workspace, owner and
+// access URL are read from the build context source and environment instead.
+type appURLFunction struct {
+	source BuildContextSource
+}
+
+func (*appURLFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "app_url"
+}
+
+func (*appURLFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Returns the path-based URL of a `coder_app`.",
+		MarkdownDescription: "Returns the path-based URL of a `coder_app` in the workspace being built, e.g. " +
+			"`https://coder.example.com/@alice/dev.main/apps/code-server/`. The access URL, workspace and owner " +
+			"are read from the `CODER_AGENT_URL` environment variable and the workspace build context. Provider " +
+			"arguments such as `url` and `simulate` do not apply, since Terraform may call functions before the " +
+			"provider is configured.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "agent",
+				Description: "The name of the `coder_agent` serving the app.",
+			},
+			function.StringParameter{
+				Name:        "slug",
+				Description: "The slug of the `coder_app`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *appURLFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var agent, slug string
+	resp.Error = req.Arguments.Get(ctx, &agent, &slug)
+	if resp.Error != nil {
+		return
+	}
+	if agent == "" {
+		resp.Error = function.NewArgumentFuncError(0, "agent must not be empty")
+		return
+	}
+	if !appSlugRegex.MatchString(slug) {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("invalid app slug %q, must match %q", slug, appSlugRegex.String()))
+		return
+	}
+
+	bc, err := f.buildContext()
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	rawURL := os.Getenv("CODER_AGENT_URL")
+	if rawURL == "" {
+		rawURL = defaultAccessURL
+	}
+	accessURL, err := url.Parse(rawURL)
+	if err != nil {
+		resp.Error = function.NewFuncError(fmt.Sprintf("parse CODER_AGENT_URL: %s", err))
+		return
+	}
+
+	owner := bc.Owner.Name
+	if owner == "" {
+		owner = "default"
+	}
+	workspace := bc.Workspace.Name
+	if workspace == "" {
+		workspace = "default"
+	}
+	appURL := accessURL.JoinPath("@"+owner, workspace+"."+agent, "apps", slug)
+	appURL.Path += "/"
+	resp.Error = resp.Result.Set(ctx, appURL.String())
+}
+
+// buildContext loads the build context the same way the provider does when
+// configured, honoring CODER_BUILD_CONTEXT_FILE.
+func (f *appURLFunction) buildContext() (*BuildContext, error) {
+	bc, err := f.source()
+	if err != nil {
+		return nil, fmt.Errorf("load build context: %w", err)
+	}
+	if bc == nil {
+		bc = &BuildContext{}
+	}
+	if path := os.Getenv(BuildContextFileEnvironmentVariable()); path != "" {
+		bc = bc.clone()
+		if err := bc.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return bc, nil
+}
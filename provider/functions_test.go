@@ -0,0 +1,101 @@
+package provider_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	bc := &provider.BuildContext{
+		Workspace: provider.WorkspaceBuildContext{Name: "dev"},
+		Owner:     provider.OwnerBuildContext{Name: "alice"},
+	}
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "EnvNames",
+		Config: `
+		output "parameter" {
+			value = provider::coder::parameter_env_name("region")
+		}
+		output "secret_env" {
+			value = provider::coder::secret_env_name("GITHUB_TOKEN")
+		}
+		output "secret_file" {
+			value = provider::coder::secret_file_env_name("~/.ssh/id_rsa")
+		}
+		output "agent_token" {
+			value = provider::coder::running_agent_token_env_name("")
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckOutput("parameter", provider.ParameterEnvironmentVariable("region")),
+			resource.TestCheckOutput("secret_env", provider.SecretEnvEnvironmentVariable("GITHUB_TOKEN")),
+			resource.TestCheckOutput("secret_file", provider.SecretFileEnvironmentVariable("~/.ssh/id_rsa")),
+			resource.TestCheckOutput("agent_token", provider.RunningAgentTokenEnvironmentVariable("")),
+		),
+	}, {
+		Name: "ValidateCron",
+		Config: `
+		output "valid" {
+			value = provider::coder::validate_cron("0 */5 * * * *")
+		}
+		output "invalid" {
+			value = provider::coder::validate_cron("every five minutes")
+		}`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckOutput("valid", "true"),
+			resource.TestCheckOutput("invalid", "false"),
+		),
+	}, {
+		Name: "ParseListString",
+		Config: `
+		output "items" {
+			value = join(",", provider::coder::parse_list_string("[\"a\",\"b\"]"))
+		}`,
+		Check: resource.TestCheckOutput("items", "a,b"),
+	}, {
+		Name: "ParseListStringInvalid",
+		Config: `
+		output "items" {
+			value = provider::coder::parse_list_string("a,b")
+		}`,
+		ExpectError: regexp.MustCompile(`is not a valid list of`),
+	}, {
+		Name: "AppURL",
+		Config: `
+		output "url" {
+			value = provider::coder::app_url("main", "code-server")
+		}`,
+		Check: resource.TestCheckOutput("url", "https://mydeployment.coder.com/@alice/dev.main/apps/code-server/"),
+	}, {
+		Name: "AppURLInvalidSlug",
+		Config: `
+		output "url" {
+			value = provider::coder::app_url("main", "Code Server")
+		}`,
+		ExpectError: regexp.MustCompile(`invalid app slug`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(bc),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config:      requiredProviders + tc.Config,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/xerrors"
+)
+
+// importWithAttributes returns an importer for resources that have no
+// upstream API to read from. Attributes which force a new resource cannot be
+// recovered by Read, so they are passed in the import ID after the resource
+// ID, separated by colons:
+//
+//	<id>:<required...>[:<optional...>]
+//
+// Values that contain a colon, such as Windows paths, are quoted with double
+// quotes or backquotes as in Go, e.g. `C:\workspace`. Omitted optional
+// attributes, and every other attribute with a schema default, are set to
+// their default so that importing does not plan a replacement.
+func importWithAttributes(resource *schema.Resource, required []string, optional ...string) *schema.ResourceImporter {
+	return &schema.ResourceImporter{
+		StateContext: func(_ context.Context, rd *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+			format := importIDFormat(required, optional)
+			parts, err := splitImportID(rd.Id())
+			if err != nil {
+				return nil, err
+			}
+			values := parts[1:]
+			if len(values) < len(required) || len(values) > len(required)+len(optional) {
+				return nil, xerrors.Errorf("unexpected import ID %q, expected %q", rd.Id(), format)
+			}
+			if _, err := uuid.Parse(parts[0]); err != nil {
+				return nil, xerrors.Errorf("invalid ID %q in import ID, expected a UUID: %w", parts[0], err)
+			}
+			rd.SetId(parts[0])
+
+			attrs := append(append([]string{}, required...), optional...)
+			imported := map[string]bool{}
+			for i, value := range values {
+				if err := setImportedAttribute(resource.Schema[attrs[i]], rd, attrs[i], value); err != nil {
+					return nil, err
+				}
+				imported[attrs[i]] = true
+			}
+			for name, attr := range resource.Schema {
+				if attr.Default == nil || imported[name] {
+					continue
+				}
+				if err := rd.Set(name, attr.Default); err != nil {
+					return nil, xerrors.Errorf("set default %q: %w", name, err)
+				}
+			}
+			return []*schema.ResourceData{rd}, nil
+		},
+	}
+}
+
+// splitImportID splits an import ID on colons, except those in quoted values.
+func splitImportID(id string) ([]string, error) {
+	var parts []string
+	rest := id
+	for {
+		if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, "`") {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, xerrors.Errorf("unterminated quoted value %q in import ID %q", rest, id)
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid quoted value %s in import ID %q: %w", quoted, id, err)
+			}
+			parts = append(parts, value)
+			rest = rest[len(quoted):]
+			if rest == "" {
+				return parts, nil
+			}
+			if rest[0] != ':' {
+				return nil, xerrors.Errorf("unexpected %q after quoted value %s in import ID %q", rest, quoted, id)
+			}
+			rest = rest[1:]
+			continue
+		}
+		value, next, found := strings.Cut(rest, ":")
+		parts = append(parts, value)
+		if !found {
+			return parts, nil
+		}
+		rest = next
+	}
+}
+
+// importIDFormat describes the import ID, e.g. "<id>:<os>:<arch>[:<auth>]".
+func importIDFormat(required, optional []string) string {
+	var b strings.Builder
+	b.WriteString("<id>")
+	for _, attr := range required {
+		fmt.Fprintf(&b, ":<%s>", attr)
+	}
+	for _, attr := range optional {
+		fmt.Fprintf(&b, "[:<%s>", attr)
+	}
+	b.WriteString(strings.Repeat("]", len(optional)))
+	return b.String()
+}
+
+// setImportedAttribute validates value the same way as the configuration
+// would be, and sets it. Sets of strings are passed as comma-separated
+// values.
+func setImportedAttribute(attr *schema.Schema, rd *schema.ResourceData, name, value string) error {
+	if attr.Type == schema.TypeSet {
+		elem, _ := attr.Elem.(*schema.Schema)
+		if elem == nil || elem.Type != schema.TypeString {
+			return xerrors.Errorf("%s cannot be set in an import ID", name)
+		}
+		if value == "" {
+			return xerrors.Errorf("invalid %s in import ID: must not be empty", name)
+		}
+		values := strings.Split(value, ",")
+		elems := make([]interface{}, 0, len(values))
+		for i, value := range values {
+			if elem.ValidateFunc != nil {
+				if _, errs := elem.ValidateFunc(value, fmt.Sprintf("%s.%d", name, i)); len(errs) > 0 {
+					return xerrors.Errorf("invalid %s %q in import ID: %w", name, value, errs[0])
+				}
+			}
+			elems = append(elems, value)
+		}
+		if err := rd.Set(name, elems); err != nil {
+			return xerrors.Errorf("set %q: %w", name, err)
+		}
+		return nil
+	}
+	if attr.ValidateFunc != nil {
+		_, errs := attr.ValidateFunc(value, name)
+		if len(errs) > 0 {
+			return xerrors.Errorf("invalid %s %q in import ID: %w", name, value, errs[0])
+		}
+	}
+	if attr.ValidateDiagFunc != nil {
+		diags := attr.ValidateDiagFunc(value, cty.GetAttrPath(name))
+		if diags.HasError() {
+			return xerrors.Errorf("invalid %s %q in import ID: %s", name, value, diags[0].Summary)
+		}
+	}
+	if err := rd.Set(name, value); err != nil {
+		return xerrors.Errorf("set %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package provider_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	const agent = `
+		provider "coder" {
+		}
+		resource "coder_agent" "dev" {
+			os = "linux"
+			arch = "amd64"
+			auth = "aws-instance-identity"
+		}
+		`
+
+	for _, tc := range []struct {
+		Name   string
+		Config string
+		// Attributes are appended to the ID to form the import ID.
+		Attributes []string
+		// Ignore lists attributes that are not part of the import ID.
+		Ignore []string
+	}{{
+		Name:       "coder_agent.dev",
+		Config:     agent,
+		Attributes: []string{"os", "arch", "auth"},
+		// The token is regenerated on every read.
+		Ignore: []string{"token"},
+	}, {
+		Name: "coder_app.code-server",
+		Config: agent + `
+			resource "coder_app" "code-server" {
+				agent_id = coder_agent.dev.id
+				slug = "code-server"
+				display_name = "code-server"
+				url = "http://localhost:13337"
+			}
+			`,
+		Attributes: []string{"agent_id", "slug"},
+		Ignore:     []string{"display_name", "url"},
+	}, {
+		Name: "coder_script.example",
+		Config: agent + `
+			resource "coder_script" "example" {
+				agent_id = coder_agent.dev.id
+				display_name = "Hey"
+				script = "echo hey"
+				run_on_start = true
+			}
+			`,
+		Attributes: []string{"agent_id"},
+		Ignore:     []string{"display_name", "script", "run_on_start"},
+	}, {
+		Name: "coder_env.example",
+		Config: agent + `
+			resource "coder_env" "example" {
+				agent_id = coder_agent.dev.id
+				name = "PATH"
+				value = "/usr/local/bin"
+			}
+			`,
+		Attributes: []string{"agent_id", "name"},
+		Ignore:     []string{"value"},
+	}, {
+		Name: "coder_devcontainer.example",
+		Config: agent + `
+			resource "coder_devcontainer" "example" {
+				agent_id = coder_agent.dev.id
+				workspace_folder = "/workspaces/coder"
+				config_path = ".devcontainer/devcontainer.json"
+			}
+			`,
+		Attributes: []string{"subagent_id", "agent_id", "workspace_folder", "config_path"},
+	}, {
+		// Values that contain a colon are quoted.
+		Name: "coder_devcontainer.windows",
+		Config: agent + `
+			resource "coder_devcontainer" "windows" {
+				agent_id = coder_agent.dev.id
+				workspace_folder = "C:\\workspaces\\coder"
+				config_path = "C:\\workspaces\\coder\\.devcontainer\\devcontainer.json"
+			}
+			`,
+		Attributes: []string{"subagent_id", "agent_id", "workspace_folder", "config_path"},
+	}, {
+		Name: "coder_metadata.example",
+		Config: agent + `
+			resource "coder_metadata" "example" {
+				resource_id = coder_agent.dev.id
+				item {
+					key = "foo"
+					value = "bar"
+				}
+			}
+			`,
+		Attributes: []string{"resource_id"},
+		Ignore:     []string{"item"},
+	}, {
+		Name: "coder_external_agent.example",
+		Config: agent + `
+			resource "coder_external_agent" "example" {
+				agent_id = coder_agent.dev.id
+			}
+			`,
+		Attributes: []string{"agent_id"},
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config: tc.Config,
+				}, {
+					Config:       tc.Config,
+					ResourceName: tc.Name,
+					ImportState:  true,
+					ImportStateIdFunc: func(state *terraform.State) (string, error) {
+						res := state.RootModule().Resources[tc.Name]
+						parts := []string{res.Primary.ID}
+						for _, attr := range tc.Attributes {
+							value := res.Primary.Attributes[attr]
+							if strings.Contains(value, ":") {
+								value = "`" + value + "`"
+							}
+							parts = append(parts, value)
+						}
+						return strings.Join(parts, ":"), nil
+					},
+					ImportStateVerify:       true,
+					ImportStateVerifyIgnore: tc.Ignore,
+				}},
+			})
+		})
+	}
+}
+
+func TestImportBlock(t *testing.T) {
+	t.Parallel()
+
+	// Import blocks are how existing agents and apps are adopted after
+	// moving them into a module. Attributes missing from the import ID are
+	// updated in place, without replacing the resource.
+	const (
+		agentID = "5e0a2d4c-8d7e-4e0b-a1e5-2f3c6b7d8e9f"
+		appID   = "0c9f4b1a-6d2e-4f7a-9b3c-1e8d5a2f6c4b"
+	)
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactory(),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: fmt.Sprintf(`
+				provider "coder" {
+				}
+				import {
+					to = coder_agent.dev
+					id = "%[1]s:linux:amd64"
+				}
+				import {
+					to = coder_app.code-server
+					id = "%[2]s:%[1]s:code-server"
+				}
+				resource "coder_agent" "dev" {
+					os = "linux"
+					arch = "amd64"
+					dir = "/home/coder"
+				}
+				resource "coder_app" "code-server" {
+					agent_id = coder_agent.dev.id
+					slug = "code-server"
+					display_name = "code-server"
+					url = "http://localhost:13337"
+				}
+				`, agentID, appID),
+			Check: resource.ComposeTestCheckFunc(
+				resource.TestCheckResourceAttr("coder_agent.dev", "id", agentID),
+				resource.TestCheckResourceAttr("coder_agent.dev", "dir", "/home/coder"),
+				resource.TestCheckResourceAttr("coder_agent.dev", "auth", "token"),
+				resource.TestCheckResourceAttrSet("coder_agent.dev", "token"),
+				resource.TestCheckResourceAttr("coder_app.code-server", "id", appID),
+				resource.TestCheckResourceAttr("coder_app.code-server", "agent_id", agentID),
+				resource.TestCheckResourceAttr("coder_app.code-server", "url", "http://localhost:13337"),
+			),
+		}},
+	})
+}
+
+func TestImportInvalidID(t *testing.T) {
+	t.Parallel()
+
+	const config = `
+		provider "coder" {
+		}
+		resource "coder_agent" "dev" {
+			os = "linux"
+			arch = "amd64"
+		}
+		`
+	const id = "8b8b2a3e-52b3-4a3e-9b43-6f1b4b1f4c8a"
+
+	for _, tc := range []struct {
+		Name     string
+		ImportID string
+		Error    string
+	}{{
+		Name:     "MissingAttributes",
+		ImportID: id,
+		Error:    `expected "<id>:<os>:<arch>\[:<auth>\[:<api_key_scope>\[:<api_key_scopes>\]\]\]"`,
+	}, {
+		Name:     "TooManyAttributes",
+		ImportID: id + ":linux:amd64:token:all:metadata_read:extra",
+		Error:    `unexpected import ID`,
+	}, {
+		Name:     "InvalidID",
+		ImportID: "dev:linux:amd64",
+		Error:    `invalid ID "dev" in import ID, expected a UUID`,
+	}, {
+		Name:     "UnterminatedQuote",
+		ImportID: id + `:linux:"amd64`,
+		Error:    `unterminated quoted value`,
+	}, {
+		Name:     "TextAfterQuote",
+		ImportID: id + `:"linux"amd64`,
+		Error:    `unexpected "amd64" after quoted value "linux"`,
+	}, {
+		Name:     "QuotedAttribute",
+		ImportID: id + `:"plan9:x":amd64`,
+		Error:    `invalid os "plan9:x" in import ID`,
+	}, {
+		Name:     "InvalidAttribute",
+		ImportID: id + ":plan9:amd64",
+		Error:    `invalid os "plan9" in import ID`,
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: coderFactory(),
+				IsUnitTest:        true,
+				Steps: []resource.TestStep{{
+					Config:        config,
+					ResourceName:  "coder_agent.dev",
+					ImportState:   true,
+					ImportStateId: tc.ImportID,
+					ExpectError:   regexp.MustCompile(tc.Error),
+				}},
+			})
+		})
+	}
+}
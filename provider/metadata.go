@@ -12,7 +12,7 @@ import (
 )
 
 func metadataResource() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		SchemaVersion: 1,
 
 		Description: "Use this resource to attach metadata to a resource. They will be " +
@@ -21,17 +21,12 @@ func metadataResource() *schema.Resource {
 			"Alternatively, to attach metadata to the agent, use a `metadata` block within a `coder_agent` resource.",
 		CreateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			resourceData.SetId(uuid.NewString())
-
-			items, err := populateIsNull(resourceData)
-			if err != nil {
-				return errorAsDiagnostics(err)
-			}
-			err = resourceData.Set("item", items)
-			if err != nil {
-				return errorAsDiagnostics(err)
-			}
-
-			return nil
+			return setMetadataItems(resourceData)
+		},
+		// Only resource_id forces new metadata, everything else is updated in
+		// place.
+		UpdateContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
+			return setMetadataItems(resourceData)
 		},
 		ReadContext: func(c context.Context, resourceData *schema.ResourceData, i interface{}) diag.Diagnostics {
 			return nil
@@ -49,7 +44,6 @@ func metadataResource() *schema.Resource {
 			"hide": {
 				Type:        schema.TypeBool,
 				Description: "Hide the resource from the UI.",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"icon": {
@@ -57,7 +51,6 @@ func metadataResource() *schema.Resource {
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons [here](https://github.com/coder/coder/tree/main/site/static/icon). Use a " +
 					"built-in icon with `\"${data.coder_workspace.me.access_url}/icon/<path>\"`.",
-				ForceNew:     true,
 				Optional:     true,
 				ValidateFunc: helpers.ValidateURL,
 			},
@@ -66,26 +59,22 @@ func metadataResource() *schema.Resource {
 				Description: "(Enterprise) The cost of this resource every 24 hours." +
 					" Use the smallest denomination of your preferred currency." +
 					" For example, if you work in USD, use cents.",
-				ForceNew: true,
 				Optional: true,
 			},
 			"item": {
 				Type:        schema.TypeList,
 				Description: "Each `item` block defines a single metadata item consisting of a key/value pair.",
-				ForceNew:    true,
 				Optional:    true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"key": {
 							Type:        schema.TypeString,
 							Description: "The key of this metadata item.",
-							ForceNew:    true,
 							Required:    true,
 						},
 						"value": {
 							Type:        schema.TypeString,
 							Description: "The value of this metadata item. Supports basic Markdown, including hyperlinks.",
-							ForceNew:    true,
 							Optional:    true,
 						},
 						"sensitive": {
@@ -94,13 +83,11 @@ func metadataResource() *schema.Resource {
 								"hidden from view by default. Note that this does not prevent metadata from " +
 								"being retrieved using the API, so it is not suitable for secrets that should " +
 								"not be exposed to workspace users.",
-							ForceNew: true,
 							Optional: true,
 							Default:  false,
 						},
 						"is_null": {
 							Type:     schema.TypeBool,
-							ForceNew: true,
 							Computed: true,
 						},
 					},
@@ -134,4 +121,20 @@ func metadataResource() *schema.Resource {
 			return nil
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"resource_id"})
+	return resource
+}
+
+// setMetadataItems sets "item" from the raw plan, so that null values are
+// recorded in "is_null".
+func setMetadataItems(resourceData *schema.ResourceData) diag.Diagnostics {
+	items, err := populateIsNull(resourceData)
+	if err != nil {
+		return errorAsDiagnostics(err)
+	}
+	err = resourceData.Set("item", items)
+	if err != nil {
+		return errorAsDiagnostics(err)
+	}
+	return nil
 }
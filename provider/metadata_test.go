@@ -1,6 +1,7 @@
 package provider_test
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -88,6 +89,51 @@ func TestMetadata(t *testing.T) {
 	})
 }
 
+func TestMetadataUpdate(t *testing.T) {
+	t.Parallel()
+
+	metadataConfig := func(resourceID, value string) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+			}
+			resource "coder_metadata" "agent" {
+				resource_id = %q
+				item {
+					key = "foo"
+					value = %s
+				}
+			}
+			`, resourceID, value)
+	}
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactory(),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: metadataConfig("resource", `"bar"`),
+			Check:  checkResourceID(t, "coder_metadata.agent", &id, true),
+		}, {
+			// Null values are still detected on update.
+			Config: metadataConfig("resource", "null"),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_metadata.agent", &id, true),
+				resource.TestCheckResourceAttr("coder_metadata.agent", "item.0.is_null", "true"),
+			),
+		}, {
+			Config: metadataConfig("resource", `"baz"`),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_metadata.agent", &id, true),
+				resource.TestCheckResourceAttr("coder_metadata.agent", "item.0.value", "baz"),
+				resource.TestCheckResourceAttr("coder_metadata.agent", "item.0.is_null", "false"),
+			),
+		}, {
+			Config: metadataConfig("another-resource", `"baz"`),
+			Check:  checkResourceID(t, "coder_metadata.agent", &id, false),
+		}},
+	})
+}
+
 func TestMetadataDuplicateKeys(t *testing.T) {
 	t.Parallel()
 	resource.Test(t, resource.TestCase{
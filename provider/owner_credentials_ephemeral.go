@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ ephemeral.EphemeralResourceWithConfigure = &ownerCredentialsEphemeralResource{}
+
+func newOwnerCredentialsEphemeralResource() ephemeral.EphemeralResource {
+	return &ownerCredentialsEphemeralResource{}
+}
+
+// ownerCredentialsEphemeralResource exposes the sensitive attributes of the
+// coder_workspace_owner data source without persisting them.
+type ownerCredentialsEphemeralResource struct {
+	buildContext *BuildContext
+}
+
+type ownerCredentialsEphemeralResourceModel struct {
+	SessionToken    types.String `tfsdk:"session_token"`
+	OIDCAccessToken types.String `tfsdk:"oidc_access_token"`
+	SSHPrivateKey   types.String `tfsdk:"ssh_private_key"`
+}
+
+func (*ownerCredentialsEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_owner_credentials"
+}
+
+func (*ownerCredentialsEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this ephemeral resource to read the credentials of the workspace owner without " +
+			"persisting them to the Terraform plan or state. It exposes the same values as the " +
+			"`session_token`, `oidc_access_token` and `ssh_private_key` attributes of the " +
+			"`coder_workspace_owner` data source. Requires Terraform v1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"session_token": schema.StringAttribute{
+				Description: "Session token for authenticating with a Coder deployment. It is regenerated every time a workspace is started.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"oidc_access_token": schema.StringAttribute{
+				Description: "A valid OpenID Connect access token of the workspace owner. " +
+					"This is only available if the workspace owner authenticated with OpenID Connect. " +
+					"If a valid token cannot be obtained, this value will be an empty string.",
+				Computed:  true,
+				Sensitive: true,
+			},
+			"ssh_private_key": schema.StringAttribute{
+				Description: "The user's generated SSH private key.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *ownerCredentialsEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.buildContext = ephemeralBuildContext(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *ownerCredentialsEphemeralResource) Open(ctx context.Context, _ ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	owner := r.buildContext.Owner
+	resp.Diagnostics.Append(resp.Result.Set(ctx, ownerCredentialsEphemeralResourceModel{
+		SessionToken:    types.StringValue(owner.SessionToken),
+		OIDCAccessToken: types.StringValue(owner.OIDCAccessToken),
+		SSHPrivateKey:   types.StringValue(owner.SSHPrivateKey),
+	})...)
+}
@@ -0,0 +1,61 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestOwnerCredentialsEphemeral(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{
+				Owner: provider.OwnerBuildContext{
+					SessionToken:    "session-token",
+					OIDCAccessToken: "oidc-token",
+					SSHPrivateKey:   "private-key",
+				},
+			}),
+			IsUnitTest: true,
+			Steps: []resource.TestStep{{
+				Config: `
+				ephemeral "coder_owner_credentials" "me" {}
+				` + ephemeralCheck(`ephemeral.coder_owner_credentials.me.session_token == "session-token" && `+
+					`ephemeral.coder_owner_credentials.me.oidc_access_token == "oidc-token" && `+
+					`ephemeral.coder_owner_credentials.me.ssh_private_key == "private-key"`),
+			}},
+		})
+	})
+
+	// The plugin-framework provider must apply provider arguments the same
+	// way as the SDKv2 provider.
+	t.Run("Simulate", func(t *testing.T) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+			IsUnitTest:               true,
+			Steps: []resource.TestStep{{
+				Config: `
+				provider "coder" {
+					simulate {
+						secrets = {
+							GITHUB_TOKEN = "simulated"
+						}
+					}
+				}
+				ephemeral "coder_secret" "github" {
+					env          = "GITHUB_TOKEN"
+					help_message = "Add a GitHub token"
+				}
+				` + ephemeralCheck(`ephemeral.coder_secret.github.value == "simulated"`),
+			}},
+		})
+	})
+}
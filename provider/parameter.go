@@ -6,7 +6,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -139,16 +138,21 @@ func parameterDataSource() *schema.Resource {
 				return diag.Errorf("ephemeral parameter requires the default property")
 			}
 
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
+
 			var input *string
-			envValue, ok := os.LookupEnv(ParameterEnvironmentVariable(parameter.Name))
+			inputValue, ok := bc.parameter(parameter.Name)
 			if ok {
-				input = &envValue
+				input = &inputValue
 			}
 
 			var previous *string
-			envPreviousValue, ok := os.LookupEnv(ParameterEnvironmentVariablePrevious(parameter.Name))
+			previousValue, ok := bc.previousParameter(parameter.Name)
 			if ok {
-				previous = &envPreviousValue
+				previous = &previousValue
 			}
 
 			value, diags := parameter.ValidateInput(input, previous)
@@ -332,6 +336,10 @@ func parameterDataSource() *schema.Resource {
 	}
 }
 
+// fixValidationResourceData sets "min_disabled" and "max_disabled" on the
+// validation rule from the raw config, since SDKv2 cannot tell a null "min"
+// or "max" apart from zero. See "Migrating from SDKv2" in the README for how
+// to remove this by moving coder_parameter to the plugin framework.
 func fixValidationResourceData(rawConfig cty.Value, validation interface{}) (interface{}, error) {
 	// Read validation from raw config
 	rawValidation, ok := rawConfig.AsValueMap()["validation"]
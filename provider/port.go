@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithValidateConfig = &portResource{}
+	_ resource.ResourceWithImportState    = &portResource{}
+)
+
+var portProtocols = []string{"http", "https", "tcp"}
+
+func newPortResource() resource.Resource {
+	return &portResource{}
+}
+
+// portResource declares a port that an agent exposes, and the level it is
+// shared at. Like coder_app, it has no upstream API: coderd reads it from
+// the state after a build.
+type portResource struct{}
+
+type portResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	AgentID     types.String `tfsdk:"agent_id"`
+	Port        types.Int64  `tfsdk:"port"`
+	Protocol    types.String `tfsdk:"protocol"`
+	Share       types.String `tfsdk:"share"`
+	DisplayName types.String `tfsdk:"display_name"`
+}
+
+func (*portResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_port"
+}
+
+func (*portResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Version: 0,
+		Description: "Use this resource to declare a port that an agent exposes, and the level it is shared " +
+			"at. Declared ports are listed in the port forwarding menu of the workspace, whether or not " +
+			"`display_apps.port_forwarding_helper` is enabled on the agent.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The ID of this resource.",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"agent_id": schema.StringAttribute{
+				Description: "The `id` property of a `coder_agent` resource to associate with.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"port": schema.Int64Attribute{
+				Description: "The port the agent exposes. Must be between 1 and 65535.",
+				Required:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.RequiresReplace(),
+				},
+			},
+			"protocol": schema.StringAttribute{
+				Description: "The protocol of the port. Valid protocols are `\"http\"` (default), `\"https\"` " +
+					"and `\"tcp\"`. HTTP and HTTPS ports are shared through the Coder dashboard, and TCP ports " +
+					"through `coder port-forward`.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("http"),
+			},
+			"share": schema.StringAttribute{
+				Description: "Determines the level which the port is shared at. Valid levels are `\"owner\"` " +
+					"(default), `\"authenticated\"`, `\"organization\"` and `\"public\"`. Level `\"owner\"` " +
+					"disables sharing on the port, so only the workspace owner can access it. Level " +
+					"`\"authenticated\"` shares the port with all authenticated users. Level `\"organization\"` " +
+					"shares it with the members of the workspace's organization. Level `\"public\"` shares " +
+					"it with any user, including unauthenticated users. Permitted sharing levels can be " +
+					"configured site-wide via a flag on `coder server` (Enterprise only).",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("owner"),
+			},
+			"display_name": schema.StringAttribute{
+				Description: "A display name to identify the port. Defaults to the port number.",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (*portResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var model portResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !model.Port.IsNull() && !model.Port.IsUnknown() {
+		if port := model.Port.ValueInt64(); port < 1 || port > 65535 {
+			resp.Diagnostics.AddAttributeError(path.Root("port"), "Invalid port",
+				fmt.Sprintf("expected port to be in the range (1 - 65535), got %d", port))
+		}
+	}
+	if !model.Protocol.IsNull() && !model.Protocol.IsUnknown() && !slices.Contains(portProtocols, model.Protocol.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("protocol"), "Invalid protocol",
+			fmt.Sprintf("invalid port protocol %q, must be one of %s", model.Protocol.ValueString(), quoteList(portProtocols)))
+	}
+	if !model.Share.IsNull() && !model.Share.IsUnknown() && !slices.Contains(shareLevels, model.Share.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("share"), "Invalid share",
+			fmt.Sprintf("invalid port share %q, must be one of %s", model.Share.ValueString(), quoteList(shareLevels)))
+	}
+}
+
+func (*portResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var model portResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	model.ID = types.StringValue(uuid.NewString())
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (*portResource) Read(context.Context, resource.ReadRequest, *resource.ReadResponse) {
+	// The state is the source of truth, so there is nothing to refresh.
+}
+
+func (*portResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var model portResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+func (*portResource) Delete(context.Context, resource.DeleteRequest, *resource.DeleteResponse) {}
+
+// ImportState accepts the same import ID format as the SDKv2 resources,
+// "<id>:<agent_id>:<port>[:<protocol>[:<share>[:<display_name>]]]", including
+// quoted values. Omitted attributes are set to their defaults.
+func (*portResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	format := importIDFormat([]string{"agent_id", "port"}, []string{"protocol", "share", "display_name"})
+	parts, err := splitImportID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+	if len(parts) < 3 || len(parts) > 6 {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("unexpected import ID %q, expected %q", req.ID, format))
+		return
+	}
+	if _, err := uuid.Parse(parts[0]); err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("invalid ID %q in import ID, expected a UUID: %s", parts[0], err))
+		return
+	}
+	port, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || port < 1 || port > 65535 {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("invalid port %q in import ID", parts[2]))
+		return
+	}
+	model := portResourceModel{
+		ID:          types.StringValue(parts[0]),
+		AgentID:     types.StringValue(parts[1]),
+		Port:        types.Int64Value(port),
+		Protocol:    types.StringValue("http"),
+		Share:       types.StringValue("owner"),
+		DisplayName: types.StringNull(),
+	}
+	if len(parts) > 3 {
+		if !slices.Contains(portProtocols, parts[3]) {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("invalid protocol %q in import ID", parts[3]))
+			return
+		}
+		model.Protocol = types.StringValue(parts[3])
+	}
+	if len(parts) > 4 {
+		if !slices.Contains(shareLevels, parts[4]) {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("invalid share %q in import ID", parts[4]))
+			return
+		}
+		model.Share = types.StringValue(parts[4])
+	}
+	if len(parts) > 5 {
+		model.DisplayName = types.StringValue(parts[5])
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, model)...)
+}
+
+// quoteList formats values as a comma-separated list of quoted strings, e.g.
+// `"owner", "public"`.
+func quoteList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,167 @@
+package provider_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestPort(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		Name        string
+		Config      string
+		Check       resource.TestCheckFunc
+		ExpectError *regexp.Regexp
+	}{{
+		Name: "Defaults",
+		Config: `
+			port = 8080`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttrSet("coder_port.dev", "id"),
+			resource.TestCheckResourceAttrPair("coder_port.dev", "agent_id", "coder_agent.dev", "id"),
+			resource.TestCheckResourceAttr("coder_port.dev", "port", "8080"),
+			resource.TestCheckResourceAttr("coder_port.dev", "protocol", "http"),
+			resource.TestCheckResourceAttr("coder_port.dev", "share", "owner"),
+			resource.TestCheckNoResourceAttr("coder_port.dev", "display_name"),
+		),
+	}, {
+		Name: "Custom",
+		Config: `
+			port         = 5432
+			protocol     = "tcp"
+			share        = "organization"
+			display_name = "PostgreSQL"`,
+		Check: resource.ComposeTestCheckFunc(
+			resource.TestCheckResourceAttr("coder_port.dev", "protocol", "tcp"),
+			resource.TestCheckResourceAttr("coder_port.dev", "share", "organization"),
+			resource.TestCheckResourceAttr("coder_port.dev", "display_name", "PostgreSQL"),
+		),
+	}, {
+		Name: "InvalidPort",
+		Config: `
+			port = 70000`,
+		ExpectError: regexp.MustCompile(`expected port to be in the range \(1 - 65535\), got 70000`),
+	}, {
+		Name: "InvalidProtocol",
+		Config: `
+			port     = 8080
+			protocol = "udp"`,
+		ExpectError: regexp.MustCompile(`invalid port protocol "udp", must be one of "http", "https", "tcp"`),
+	}, {
+		Name: "InvalidShare",
+		Config: `
+			port  = 8080
+			share = "everyone"`,
+		ExpectError: regexp.MustCompile(`invalid port share "everyone", must be one of "owner", "authenticated",\s+"organization", "public"`),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config: `
+						provider "coder" {
+							url = "https://example.com"
+						}
+						resource "coder_agent" "dev" {
+							os = "linux"
+							arch = "amd64"
+						}
+						resource "coder_port" "dev" {
+							agent_id = coder_agent.dev.id
+							` + tc.Config + `
+						}`,
+					Check:       tc.Check,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
+
+func TestPortUpdate(t *testing.T) {
+	t.Parallel()
+
+	portConfig := func(port int, share, displayName string) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+				url = "https://example.com"
+			}
+			resource "coder_agent" "dev" {
+				os = "linux"
+				arch = "amd64"
+			}
+			resource "coder_port" "dev" {
+				agent_id     = coder_agent.dev.id
+				port         = %d
+				share        = %q
+				display_name = %q
+			}`, port, share, displayName)
+	}
+
+	var portID string
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: coderProtoV6Factory(&provider.BuildContext{}),
+		IsUnitTest:               true,
+		Steps: []resource.TestStep{{
+			Config: portConfig(8080, "owner", "Web"),
+			Check:  checkResourceID(t, "coder_port.dev", &portID, true),
+		}, {
+			// The share level and display name are updated in place.
+			Config: portConfig(8080, "public", "Website"),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_port.dev", &portID, true),
+				resource.TestCheckResourceAttr("coder_port.dev", "share", "public"),
+				resource.TestCheckResourceAttr("coder_port.dev", "display_name", "Website"),
+			),
+		}, {
+			// Changing the port replaces it.
+			Config: portConfig(8081, "public", "Website"),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_port.dev", &portID, false),
+				resource.TestCheckResourceAttr("coder_port.dev", "port", "8081"),
+			),
+		}, {
+			ResourceName:      "coder_port.dev",
+			ImportState:       true,
+			ImportStateVerify: true,
+			ImportStateIdFunc: func(state *terraform.State) (string, error) {
+				port := state.RootModule().Resources["coder_port.dev"].Primary
+				return fmt.Sprintf("%s:%s:%s:http:public:Website", port.ID, port.Attributes["agent_id"], port.Attributes["port"]), nil
+			},
+		}, {
+			// Values that contain a colon are quoted.
+			ResourceName: "coder_port.dev",
+			ImportState:  true,
+			ImportStateIdFunc: func(state *terraform.State) (string, error) {
+				port := state.RootModule().Resources["coder_port.dev"].Primary
+				return fmt.Sprintf(`%s:%s:%s:http:public:"Web: 8081"`, port.ID, port.Attributes["agent_id"], port.Attributes["port"]), nil
+			},
+			ImportStateCheck: func(states []*terraform.InstanceState) error {
+				if got := states[0].Attributes["display_name"]; got != "Web: 8081" {
+					return fmt.Errorf("expected display_name %q, got %q", "Web: 8081", got)
+				}
+				return nil
+			},
+		}, {
+			ResourceName:  "coder_port.dev",
+			ImportState:   true,
+			ImportStateId: "not-a-uuid:agent:8080",
+			ExpectError:   regexp.MustCompile(`invalid ID "not-a-uuid" in import ID, expected a UUID`),
+		}, {
+			ResourceName:  "coder_port.dev",
+			ImportState:   true,
+			ImportStateId: "8080",
+			ExpectError:   regexp.MustCompile(`unexpected import ID "8080", expected\s+"<id>:<agent_id>:<port>\[:<protocol>\[:<share>\[:<display_name>\]\]\]"`),
+		}},
+	})
+}
@@ -9,17 +9,29 @@ import (
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/mitchellh/mapstructure"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
 
+// defaultAccessURL is used as the access URL when CODER_AGENT_URL is unset.
+const defaultAccessURL = "https://mydeployment.coder.com"
+
 type config struct {
-	URL *url.URL
+	URL          *url.URL
+	BuildContext *BuildContext
 }
 
-// New returns a new Terraform provider.
+// New returns a new Terraform provider that loads its BuildContext from the
+// environment.
 func New() *schema.Provider {
+	return NewWithBuildContextSource(EnvBuildContextSource)
+}
+
+// NewWithBuildContextSource returns a new Terraform provider that loads its
+// BuildContext from the given source when configured.
+func NewWithBuildContextSource(source BuildContextSource) *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"url": {
@@ -28,9 +40,19 @@ func New() *schema.Provider {
 				Optional:    true,
 				// The "CODER_AGENT_URL" environment variable is used by default
 				// as the Access URL when generating scripts.
-				DefaultFunc:  schema.EnvDefaultFunc("CODER_AGENT_URL", "https://mydeployment.coder.com"),
+				DefaultFunc:  schema.EnvDefaultFunc("CODER_AGENT_URL", defaultAccessURL),
 				ValidateFunc: helpers.ValidateURL,
 			},
+			"build_context_file": {
+				Type: schema.TypeString,
+				Description: "Path to a JSON file describing the workspace build, as an alternative to passing " +
+					"individual `CODER_*` environment variables. Defaults to the value of the " +
+					"`CODER_BUILD_CONTEXT_FILE` environment variable. Values present in the file take " +
+					"precedence over the corresponding environment variables, which remain as a fallback.",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(BuildContextFileEnvironmentVariable(), ""),
+			},
+			"simulate": simulateSchema(),
 		},
 		ConfigureContextFunc: func(c context.Context, resourceData *schema.ResourceData) (interface{}, diag.Diagnostics) {
 			rawURL, ok := resourceData.Get("url").(string)
@@ -52,9 +74,23 @@ func New() *schema.Provider {
 				}
 				parsed.Host = rawHost
 			}
+			rawSimulate, _ := resourceData.Get("simulate").([]interface{})
+			buildContextFile, _ := resourceData.Get("build_context_file").(string)
+			buildContext, warnings, err := configureBuildContext(source, buildContextFile, rawSimulate)
+			if err != nil {
+				return nil, diag.FromErr(err)
+			}
+			var diags diag.Diagnostics
+			for _, warning := range warnings {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  warning,
+				})
+			}
 			return config{
-				URL: parsed,
-			}, nil
+				URL:          parsed,
+				BuildContext: buildContext,
+			}, diags
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"coder_workspace":        workspaceDataSource(),
@@ -81,12 +117,50 @@ func New() *schema.Provider {
 	}
 }
 
+// configureBuildContext loads the BuildContext from source, then applies the
+// build_context_file and simulate provider arguments. It is shared by the
+// SDKv2 and plugin-framework providers, so warnings are returned as plain
+// strings for each to convert into its own diagnostics.
+func configureBuildContext(source BuildContextSource, buildContextFile string, rawSimulate []interface{}) (*BuildContext, []string, error) {
+	buildContext, err := source()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("load build context: %w", err)
+	}
+	if buildContext == nil {
+		buildContext = &BuildContext{}
+	}
+	if buildContextFile != "" {
+		buildContext = buildContext.clone()
+		if err := buildContext.LoadFile(buildContextFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	var warnings []string
+	if len(rawSimulate) > 0 && rawSimulate[0] != nil {
+		if buildContext.BuildID != "" {
+			// Never let a simulate block leak into a real workspace build.
+			warnings = append(warnings, "`simulate` is ignored during workspace builds")
+		} else {
+			var simulation Simulation
+			if err := mapstructure.Decode(rawSimulate[0], &simulation); err != nil {
+				return nil, nil, xerrors.Errorf("decode simulate: %w", err)
+			}
+			buildContext = buildContext.clone()
+			buildContext.Simulate(simulation)
+		}
+	}
+	return buildContext, warnings, nil
+}
+
 // populateIsNull reads the raw plan for a coder_metadata resource being created,
 // figures out which items have null "value"s, and augments them by setting the
 // "is_null" field to true. This ugly hack is necessary because terraform-plugin-sdk
 // is designed around a old version of Terraform that didn't support nullable fields,
 // and it doesn't correctly propagate null values for primitive types.
 // Returns an interface{} representing the new value of the "item" field, or an error.
+//
+// See "Migrating from SDKv2" in the README for how to remove this by moving
+// coder_metadata to the plugin framework.
 func populateIsNull(resourceData *schema.ResourceData) (result interface{}, err error) {
 	// The cty package reports type mismatches by panicking
 	defer func() {
@@ -134,6 +208,15 @@ func valueAsBool(value cty.Value) interface{} {
 	return value.True()
 }
 
+// buildContextFromMeta returns the BuildContext the provider was configured with.
+func buildContextFromMeta(i interface{}) (*BuildContext, diag.Diagnostics) {
+	config, valid := i.(config)
+	if !valid {
+		return nil, diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+	}
+	return config.BuildContext, nil
+}
+
 // errorAsDiagnostic transforms a Go error to a diag.Diagnostics object representing a fatal error.
 func errorAsDiagnostics(err error) diag.Diagnostics {
 	return []diag.Diagnostic{{
@@ -1,8 +1,10 @@
 package provider_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
@@ -18,6 +20,19 @@ func TestProvider(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestProviderMux ensures that the SDKv2 and plugin-framework providers
+// declare identical provider schemas, which the mux server requires.
+func TestProviderMux(t *testing.T) {
+	t.Parallel()
+	server, err := provider.NewMuxServer(context.Background(), provider.EnvBuildContextSource)
+	require.NoError(t, err)
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	require.NoError(t, err)
+	require.Empty(t, resp.Diagnostics)
+	require.Contains(t, resp.DataSourceSchemas, "coder_workspace")
+	require.Contains(t, resp.Functions, "parameter_env_name")
+}
+
 // TestProviderEmpty ensures that the provider can be configured without
 // any actual input data. This is important for adding new fields
 // with backwards compatibility guarantees.
@@ -53,3 +68,67 @@ func coderFactory() map[string]func() (*schema.Provider, error) {
 		},
 	}
 }
+
+func coderFactoryWithBuildContext(bc *provider.BuildContext) map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"coder": func() (*schema.Provider, error) {
+			return provider.NewWithBuildContextSource(provider.StaticBuildContextSource(bc)), nil
+		},
+	}
+}
+
+// coderProtoV6Factory serves the muxed provider, which is required for
+// provider-defined functions.
+func coderProtoV6Factory(bc *provider.BuildContext) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"coder": func() (tfprotov6.ProviderServer, error) {
+			return provider.NewMuxServer(context.Background(), provider.StaticBuildContextSource(bc))
+		},
+	}
+}
+
+// requiredProviders declares the provider, which is required to use provider
+// functions and ephemeral resources. The test harness serves the provider
+// under the default hashicorp namespace.
+const requiredProviders = `
+terraform {
+	required_providers {
+		coder = {
+			source = "hashicorp/coder"
+		}
+	}
+}
+`
+
+// ephemeralCheck asserts a condition on ephemeral values, which cannot be
+// read back from the state, through a data source precondition.
+func ephemeralCheck(condition string) string {
+	return requiredProviders + `
+	data "coder_provisioner" "check" {
+		lifecycle {
+			precondition {
+				condition     = ` + condition + `
+				error_message = "ephemeral check failed"
+			}
+		}
+	}`
+}
+
+// checkResourceID compares the ID of the named resource with the one recorded
+// by the previous step, then records it for the next step. On the first step,
+// pass sameID as true.
+func checkResourceID(t *testing.T, name string, id *string, sameID bool) resource.TestCheckFunc {
+	return func(state *terraform.State) error {
+		res := state.Modules[0].Resources[name]
+		require.NotNil(t, res, name)
+		if *id != "" {
+			if sameID {
+				require.Equal(t, *id, res.Primary.ID, "%s was replaced", name)
+			} else {
+				require.NotEqual(t, *id, res.Primary.ID, "%s was not replaced", name)
+			}
+		}
+		*id = res.Primary.ID
+		return nil
+	}
+}
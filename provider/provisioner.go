@@ -17,11 +17,7 @@ func provisionerDataSource() *schema.Resource {
 		ReadContext: func(c context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
 			rd.SetId(uuid.NewString())
 			rd.Set("os", runtime.GOOS)
-			rd.Set("arch", runtime.GOARCH)
-			// Fix for #11782: if we're on 32-bit ARM, set arch to armv7.
-			if runtime.GOARCH == "arm" {
-				rd.Set("arch", "armv7")
-			}
+			rd.Set("arch", agentArch(runtime.GOARCH))
 
 			return nil
 		},
@@ -34,8 +30,19 @@ func provisionerDataSource() *schema.Resource {
 			"arch": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "The architecture of the host. This exposes `runtime.GOARCH` (see [Go constants](https://pkg.go.dev/runtime#pkg-constants)).",
+				Description: "The architecture of the host. This exposes `runtime.GOARCH` (see [Go constants](https://pkg.go.dev/runtime#pkg-constants)), using the name `coder_agent` expects for `arch` where they differ, e.g. `\"armv7\"` for `\"arm\"`.",
 			},
 		},
 	}
 }
+
+// agentArch maps a Go architecture to the coder_agent arch with the same
+// instruction set, so that the provisioner's arch can be passed to an agent.
+// The other architectures in agentArchitectures share their Go name.
+func agentArch(goarch string) string {
+	// Fix for #11782: if we're on 32-bit ARM, set arch to armv7.
+	if goarch == "arm" {
+		return "armv7"
+	}
+	return goarch
+}
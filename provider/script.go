@@ -41,22 +41,21 @@ func ValidateCronExpression(cronExpr string) (warnings []string, errors []error)
 }
 
 func scriptResource() *schema.Resource {
-	return &schema.Resource{
+	resource := &schema.Resource{
 		SchemaVersion: 1,
 
 		Description: "Use this resource to run a script from an agent. When multiple scripts are assigned to the same agent, they are executed in parallel.",
 		CreateContext: func(_ context.Context, rd *schema.ResourceData, _ interface{}) diag.Diagnostics {
 			rd.SetId(uuid.NewString())
-			runOnStart, _ := rd.Get("run_on_start").(bool)
-			startBlocksLogin, _ := rd.Get("start_blocks_login").(bool)
-			runOnStop, _ := rd.Get("run_on_stop").(bool)
-			cron, _ := rd.Get("cron").(string)
-
-			if !runOnStart && !runOnStop && cron == "" {
-				return diag.Errorf(`at least one of "run_on_start", "run_on_stop", or "cron" must be set`)
-			}
-			if !runOnStart && startBlocksLogin {
-				return diag.Errorf(`"start_blocks_login" can only be set if "run_on_start" is "true"`)
+			return validateScriptTriggers(rd)
+		},
+		// Only agent_id forces a new script, everything else is updated in place.
+		UpdateContext: func(_ context.Context, rd *schema.ResourceData, _ interface{}) diag.Diagnostics {
+			if diags := validateScriptTriggers(rd); diags.HasError() {
+				// Keep the previous state instead of persisting the invalid
+				// values.
+				rd.Partial(true)
+				return diags
 			}
 			return nil
 		},
@@ -72,31 +71,26 @@ func scriptResource() *schema.Resource {
 			"display_name": {
 				Type:        schema.TypeString,
 				Description: "The display name of the script to display logs in the dashboard.",
-				ForceNew:    true,
 				Required:    true,
 			},
 			"log_path": {
 				Type:        schema.TypeString,
 				Description: "The path of a file to write the logs to. If relative, it will be appended to tmp.",
-				ForceNew:    true,
 				Optional:    true,
 			},
 			"icon": {
 				Type:     schema.TypeString,
-				ForceNew: true,
 				Optional: true,
 				Description: "A URL to an icon that will display in the dashboard. View built-in " +
 					"icons [here](https://github.com/coder/coder/tree/main/site/static/icon). Use a " +
 					"built-in icon with `\"${data.coder_workspace.me.access_url}/icon/<path>\"`.",
 			},
 			"script": {
-				ForceNew:    true,
 				Type:        schema.TypeString,
 				Required:    true,
 				Description: "The content of the script that will be run.",
 			},
 			"cron": {
-				ForceNew:    true,
 				Type:        schema.TypeString,
 				Optional:    true,
 				Description: "The cron schedule to run the script on. This uses a 6-field cron expression format: `seconds minutes hours day-of-month month day-of-week`. Note that this differs from the standard Unix 5-field format by including seconds as the first field. Examples: `\"0 0 22 * * *\"` (daily at 10 PM), `\"0 */5 * * * *\"` (every 5 minutes), `\"30 0 9 * * 1-5\"` (weekdays at 9:30 AM).",
@@ -111,32 +105,47 @@ func scriptResource() *schema.Resource {
 			"start_blocks_login": {
 				Type:        schema.TypeBool,
 				Default:     false,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "This option determines whether users can log in immediately or must wait for the workspace to finish running this script upon startup. If not enabled, users may encounter an incomplete workspace when logging in. This option only sets the default, the user can still manually override the behavior.",
 			},
 			"run_on_start": {
 				Type:        schema.TypeBool,
 				Default:     false,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "This option defines whether or not the script should run when the agent starts. The script should exit when it is done to signal that the agent is ready.",
 			},
 			"run_on_stop": {
 				Type:        schema.TypeBool,
 				Default:     false,
-				ForceNew:    true,
 				Optional:    true,
 				Description: "This option defines whether or not the script should run when the agent stops. The script should exit when it is done to signal that the workspace can be stopped.",
 			},
 			"timeout": {
 				Type:         schema.TypeInt,
 				Default:      0,
-				ForceNew:     true,
 				Optional:     true,
 				Description:  "Time in seconds that the script is allowed to run. If the script does not complete within this time, the script is terminated and the agent lifecycle status is marked as timed out. A value of zero (default) means no timeout.",
 				ValidateFunc: validation.IntAtLeast(1),
 			},
 		},
 	}
+	resource.Importer = importWithAttributes(resource, []string{"agent_id"})
+	return resource
+}
+
+// validateScriptTriggers ensures that the script runs at some point, and that
+// start_blocks_login is only set for scripts which run on start.
+func validateScriptTriggers(rd *schema.ResourceData) diag.Diagnostics {
+	runOnStart, _ := rd.Get("run_on_start").(bool)
+	startBlocksLogin, _ := rd.Get("start_blocks_login").(bool)
+	runOnStop, _ := rd.Get("run_on_stop").(bool)
+	cron, _ := rd.Get("cron").(string)
+
+	if !runOnStart && !runOnStop && cron == "" {
+		return diag.Errorf(`at least one of "run_on_start", "run_on_stop", or "cron" must be set`)
+	}
+	if !runOnStart && startBlocksLogin {
+		return diag.Errorf(`"start_blocks_login" can only be set if "run_on_start" is "true"`)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package provider_test
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
@@ -49,6 +50,51 @@ func TestScript(t *testing.T) {
 	})
 }
 
+func TestScriptUpdate(t *testing.T) {
+	t.Parallel()
+
+	scriptConfig := func(agentID, script, cron string) string {
+		return fmt.Sprintf(`
+			provider "coder" {
+			}
+			resource "coder_script" "example" {
+				agent_id = %q
+				display_name = "Hey"
+				script = %q
+				cron = %s
+			}
+			`, agentID, script, cron)
+	}
+
+	var id string
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: coderFactory(),
+		IsUnitTest:        true,
+		Steps: []resource.TestStep{{
+			Config: scriptConfig("some id", "Wow", `"0 * * * * *"`),
+			Check:  checkResourceID(t, "coder_script.example", &id, true),
+		}, {
+			Config: scriptConfig("some id", "Much wow", `"0 0 * * * *"`),
+			Check: resource.ComposeTestCheckFunc(
+				checkResourceID(t, "coder_script.example", &id, true),
+				resource.TestCheckResourceAttr("coder_script.example", "script", "Much wow"),
+				resource.TestCheckResourceAttr("coder_script.example", "cron", "0 0 * * * *"),
+			),
+		}, {
+			// The same validation applies to updates.
+			Config:      scriptConfig("some id", "Much wow", "null"),
+			ExpectError: regexp.MustCompile(`at least one of "run_on_start", "run_on_stop", or "cron" must be set`),
+		}, {
+			// The failed update did not persist its values.
+			Config:   scriptConfig("some id", "Much wow", `"0 0 * * * *"`),
+			PlanOnly: true,
+		}, {
+			Config: scriptConfig("another id", "Much wow", `"0 0 * * * *"`),
+			Check:  checkResourceID(t, "coder_script.example", &id, false),
+		}},
+	})
+}
+
 func TestScriptNeverRuns(t *testing.T) {
 	t.Parallel()
 
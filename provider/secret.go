@@ -4,15 +4,12 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
 
 // posixEnvNameRegex matches a POSIX-compliant environment variable name:
@@ -30,11 +27,18 @@ func validateSecretEnv(val any, _ cty.Path) diag.Diagnostics {
 	if !ok {
 		return diag.Errorf("expected string, got %T", val)
 	}
+	if err := checkSecretEnv(s); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func checkSecretEnv(s string) error {
 	if s == "" {
 		return nil
 	}
 	if !posixEnvNameRegex.MatchString(s) {
-		return diag.Errorf(
+		return fmt.Errorf(
 			"`env` must be a POSIX-compliant identifier matching %q; got %q",
 			posixEnvNameRegex.String(), s)
 	}
@@ -50,11 +54,18 @@ func validateSecretFile(val any, _ cty.Path) diag.Diagnostics {
 	if !ok {
 		return diag.Errorf("expected string, got %T", val)
 	}
+	if err := checkSecretFile(s); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func checkSecretFile(s string) error {
 	if s == "" {
 		return nil
 	}
 	if !strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "~/") {
-		return diag.Errorf(
+		return fmt.Errorf(
 			"`file` must start with `/` or `~/`; got %q", s)
 	}
 	return nil
@@ -70,7 +81,8 @@ func secretDataSource() *schema.Resource {
 		Description: "Use this data source to declare that a workspace requires a user secret. " +
 			"Each `coder_secret` block declares a single secret requirement, matched by either " +
 			"an environment variable name (`env`) or a file path (`file`). The resolved value " +
-			"is available at build time via `data.coder_secret.<name>.value`.",
+			"is available at build time via `data.coder_secret.<name>.value`. To read the value " +
+			"without persisting it to the Terraform state, use the `coder_secret` ephemeral resource.",
 		ReadContext: func(ctx context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
 			env := rd.Get("env").(string)
 			file := rd.Get("file").(string)
@@ -89,63 +101,21 @@ func secretDataSource() *schema.Resource {
 				rd.SetId(fmt.Sprintf("file:%s", file))
 			}
 
-			// Look up the secret value from the environment variable
-			// set by the provisioner at build time.
-			var value string
-			if env != "" {
-				value = helpers.OptionalEnv(SecretEnvEnvironmentVariable(env))
-			} else {
-				value = helpers.OptionalEnv(SecretFileEnvironmentVariable(file))
-			}
-
-			if value != "" {
-				// Happy path where secret is resolved.
-				_ = rd.Set(valueKey, value)
-				return nil
-			}
-
-			// Note that an value is treated as missing. The provider cannot
-			// distinguish "user has not stored the secret" from "user stored
-			// an empty value", because both surface as an unset or empty
-			// CODER_SECRET_* env var. This means a user must have a non-empty
-			// secret value to satisfy a requirement.
-
-			// Only enforce missing secrets when we are certain this is a
-			// workspace start build. We check both conditions:
-			//  1. CODER_WORKSPACE_BUILD_ID is set (real build, not local
-			//     terraform plan)
-			//  2. CODER_WORKSPACE_TRANSITION is "start"
-			// In all other cases (stop, delete, local dev, ambiguous state)
-			// we return an empty value so the operation can proceed. This
-			// prevents a missing or deleted secret from making a workspace
-			// unstoppable or undeletable.
-			buildID := os.Getenv("CODER_WORKSPACE_BUILD_ID")
-			transition := os.Getenv("CODER_WORKSPACE_TRANSITION")
-			workspaceStartBuild := buildID != "" && transition == "start"
-			if !workspaceStartBuild {
-				_ = rd.Set(valueKey, value)
-				return nil
-			}
-
-			var requirement string
-			if env != "" {
-				requirement = fmt.Sprintf("environment variable %q", env)
-			} else {
-				requirement = fmt.Sprintf("file %q", file)
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
 			}
 
-			var detail strings.Builder
-			_, _ = fmt.Fprintf(&detail, "Required: %s\n\n", requirement)
-			if helpMessage := rd.Get("help_message").(string); helpMessage != "" {
-				_, _ = fmt.Fprintf(&detail, "Help message: %s\n\n", helpMessage)
+			value, err := resolveSecret(bc, env, file, rd.Get("help_message").(string))
+			if err != nil {
+				return diag.Diagnostics{{
+					Severity: diag.Error,
+					Summary:  err.Error(),
+					Detail:   err.Detail(),
+				}}
 			}
-			_, _ = fmt.Fprintf(&detail, "To resolve: ensure a secret exposes the %s.\n", requirement)
-
-			return diag.Diagnostics{{
-				Severity: diag.Error,
-				Summary:  fmt.Sprintf("Missing required secret: %s", requirement),
-				Detail:   detail.String(),
-			}}
+			_ = rd.Set(valueKey, value)
+			return nil
 		},
 		Schema: map[string]*schema.Schema{
 			"env": {
@@ -177,6 +147,77 @@ func secretDataSource() *schema.Resource {
 	}
 }
 
+// missingSecretError is returned by resolveSecret when a required secret is
+// not available during a workspace start build.
+type missingSecretError struct {
+	requirement string
+	helpMessage string
+}
+
+func (e *missingSecretError) Error() string {
+	return fmt.Sprintf("Missing required secret: %s", e.requirement)
+}
+
+// Detail returns guidance for resolving the error, for use as a diagnostic
+// detail.
+func (e *missingSecretError) Detail() string {
+	var detail strings.Builder
+	_, _ = fmt.Fprintf(&detail, "Required: %s\n\n", e.requirement)
+	if e.helpMessage != "" {
+		_, _ = fmt.Fprintf(&detail, "Help message: %s\n\n", e.helpMessage)
+	}
+	_, _ = fmt.Fprintf(&detail, "To resolve: ensure a secret exposes the %s.\n", e.requirement)
+	return detail.String()
+}
+
+// resolveSecret looks up the secret matched by env or file in the build
+// context. It is shared by the coder_secret data source and ephemeral
+// resource.
+func resolveSecret(bc *BuildContext, env, file, helpMessage string) (string, *missingSecretError) {
+	// Look up the secret value provided by the provisioner at
+	// build time.
+	var value string
+	if env != "" {
+		value = bc.secretEnv(env)
+	} else {
+		value = bc.secretFile(file)
+	}
+
+	if value != "" {
+		// Happy path where secret is resolved.
+		return value, nil
+	}
+
+	// Note that an value is treated as missing. The provider cannot
+	// distinguish "user has not stored the secret" from "user stored
+	// an empty value", because both surface as an unset or empty
+	// CODER_SECRET_* env var. This means a user must have a non-empty
+	// secret value to satisfy a requirement.
+
+	// Only enforce missing secrets when we are certain this is a
+	// workspace start build. We check both conditions:
+	//  1. CODER_WORKSPACE_BUILD_ID is set (real build, not local
+	//     terraform plan)
+	//  2. CODER_WORKSPACE_TRANSITION is "start"
+	// In all other cases (stop, delete, local dev, ambiguous state)
+	// we return an empty value so the operation can proceed. This
+	// prevents a missing or deleted secret from making a workspace
+	// unstoppable or undeletable.
+	workspaceStartBuild := bc.BuildID != "" && bc.Transition == "start"
+	if !workspaceStartBuild {
+		return value, nil
+	}
+
+	requirement := fmt.Sprintf("file %q", file)
+	if env != "" {
+		requirement = fmt.Sprintf("environment variable %q", env)
+	}
+	return "", &missingSecretError{
+		requirement: requirement,
+		helpMessage: helpMessage,
+	}
+}
+
 // SecretEnvEnvironmentVariable returns the environment variable used
 // to pass a user secret matched by env_name to Terraform during
 // workspace builds. The env name is used directly and assumed to be
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ ephemeral.EphemeralResourceWithConfigure      = &secretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &secretEphemeralResource{}
+)
+
+func newSecretEphemeralResource() ephemeral.EphemeralResource {
+	return &secretEphemeralResource{}
+}
+
+// secretEphemeralResource is the ephemeral counterpart of the coder_secret
+// data source. It resolves secrets the same way, but its value is never
+// written to the plan or state.
+type secretEphemeralResource struct {
+	buildContext *BuildContext
+}
+
+type secretEphemeralResourceModel struct {
+	Env         types.String `tfsdk:"env"`
+	File        types.String `tfsdk:"file"`
+	HelpMessage types.String `tfsdk:"help_message"`
+	Value       types.String `tfsdk:"value"`
+}
+
+func (*secretEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret"
+}
+
+func (*secretEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Use this ephemeral resource to read a user secret without persisting it to the Terraform " +
+			"plan or state. It accepts the same arguments as the `coder_secret` data source and fails " +
+			"workspace start builds in the same way when the secret is missing. Requires Terraform v1.10 or later.",
+		Attributes: map[string]schema.Attribute{
+			"env": schema.StringAttribute{
+				Description: "The environment variable name that this secret must inject (e.g. \"MY_TOKEN\"). Exactly one of `env` or `file` must be set.",
+				Optional:    true,
+			},
+			"file": schema.StringAttribute{
+				Description: "The file path that this secret must inject (e.g. \"~/my-token\"). Must start with `~/` or `/`. Exactly one of `env` or `file` must be set.",
+				Optional:    true,
+			},
+			"help_message": schema.StringAttribute{
+				Description: "Guidance shown in build failure logs when this secret requirement is not satisfied.",
+				Required:    true,
+			},
+			"value": schema.StringAttribute{
+				Description: "The resolved secret value. Treated as missing if empty.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *secretEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	r.buildContext = ephemeralBuildContext(req.ProviderData, &resp.Diagnostics)
+}
+
+func (*secretEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var model secretEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() || model.Env.IsUnknown() || model.File.IsUnknown() {
+		return
+	}
+	if model.Env.IsNull() == model.File.IsNull() {
+		resp.Diagnostics.AddError("Invalid secret", "exactly one of `env` or `file` must be set")
+		return
+	}
+	// Unlike SDKv2, the plugin framework tells an empty string from an unset
+	// attribute, so an empty name would otherwise open an empty secret.
+	if !model.Env.IsNull() && model.Env.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("env"), "Invalid secret env", "`env` must not be empty")
+		return
+	}
+	if !model.File.IsNull() && model.File.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("file"), "Invalid secret file", "`file` must not be empty")
+		return
+	}
+	if err := checkSecretEnv(model.Env.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("env"), "Invalid secret env", err.Error())
+	}
+	if err := checkSecretFile(model.File.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("file"), "Invalid secret file", err.Error())
+	}
+}
+
+func (r *secretEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var model secretEphemeralResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	value, err := resolveSecret(r.buildContext, model.Env.ValueString(), model.File.ValueString(), model.HelpMessage.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError(err.Error(), err.Detail())
+		return
+	}
+	model.Value = types.StringValue(value)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, model)...)
+}
+
+// ephemeralBuildContext returns the BuildContext the plugin-framework provider
+// was configured with. Before the provider is configured, it returns an empty
+// BuildContext.
+func ephemeralBuildContext(providerData any, diags *fwdiag.Diagnostics) *BuildContext {
+	if providerData == nil {
+		return &BuildContext{}
+	}
+	bc, ok := providerData.(*BuildContext)
+	if !ok {
+		diags.AddError("Unexpected provider data", fmt.Sprintf("expected *BuildContext, got %T", providerData))
+		return &BuildContext{}
+	}
+	return bc
+}
@@ -0,0 +1,112 @@
+package provider_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+func TestSecretEphemeral(t *testing.T) {
+	t.Parallel()
+
+	bc := &provider.BuildContext{}
+	bc.SetSecretEnv("GITHUB_TOKEN", "gh-token")
+	bc.SetSecretFile("~/.ssh/id_rsa", "private-key")
+
+	startBuild := &provider.BuildContext{
+		BuildID:    "build-id",
+		Transition: "start",
+	}
+
+	for _, tc := range []struct {
+		Name         string
+		BuildContext *provider.BuildContext
+		Config       string
+		ExpectError  *regexp.Regexp
+	}{{
+		Name:         "Env",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "github" {
+			env          = "GITHUB_TOKEN"
+			help_message = "Add a GitHub token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.github.value == "gh-token"`),
+	}, {
+		Name:         "File",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "ssh" {
+			file         = "~/.ssh/id_rsa"
+			help_message = "Add an SSH key"
+		}` + ephemeralCheck(`ephemeral.coder_secret.ssh.value == "private-key"`),
+	}, {
+		Name:         "MissingOutsideBuild",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "missing" {
+			env          = "MISSING"
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.missing.value == ""`),
+	}, {
+		Name:         "MissingDuringStartBuild",
+		BuildContext: startBuild,
+		Config: `
+		ephemeral "coder_secret" "missing" {
+			env          = "MISSING"
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.missing.value == ""`),
+		ExpectError: regexp.MustCompile(`Missing required secret: environment variable "MISSING"`),
+	}, {
+		Name:         "BothEnvAndFile",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "both" {
+			env          = "GITHUB_TOKEN"
+			file         = "~/.ssh/id_rsa"
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.both.value != ""`),
+		ExpectError: regexp.MustCompile("exactly one of `env` or `file` must be set"),
+	}, {
+		Name:         "EmptyEnv",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "empty" {
+			env          = ""
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.empty.value != ""`),
+		ExpectError: regexp.MustCompile("`env` must not be empty"),
+	}, {
+		Name:         "EmptyFile",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "empty" {
+			file         = ""
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.empty.value != ""`),
+		ExpectError: regexp.MustCompile("`file` must not be empty"),
+	}, {
+		Name:         "InvalidFile",
+		BuildContext: bc,
+		Config: `
+		ephemeral "coder_secret" "relative" {
+			file         = "id_rsa"
+			help_message = "Add a token"
+		}` + ephemeralCheck(`ephemeral.coder_secret.relative.value != ""`),
+		ExpectError: regexp.MustCompile("`file` must start with `/` or `~/`"),
+	}} {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			resource.Test(t, resource.TestCase{
+				ProtoV6ProviderFactories: coderProtoV6Factory(tc.BuildContext),
+				IsUnitTest:               true,
+				Steps: []resource.TestStep{{
+					Config:      tc.Config,
+					ExpectError: tc.ExpectError,
+				}},
+			})
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// simulationNamespace is used to derive stable IDs for simulated workspaces
+// and owners, so that repeated plans produce the same values.
+var simulationNamespace = uuid.MustParse("6a1f2b8e-0c3d-4b7e-9f5a-2d8c4e6b1a90")
+
+// Simulation holds the values of the provider's `simulate` block. It is used
+// to feed deterministic values to data sources when running Terraform outside
+// of Coder.
+type Simulation struct {
+	Workspace     []SimulatedWorkspace `mapstructure:"workspace"`
+	Owner         []SimulatedOwner     `mapstructure:"owner"`
+	Parameters    map[string]string    `mapstructure:"parameters"`
+	Secrets       map[string]string    `mapstructure:"secrets"`
+	ExternalAuth  map[string]string    `mapstructure:"external_auth"`
+	Transition    string               `mapstructure:"transition"`
+	Prebuild      bool                 `mapstructure:"prebuild"`
+	PrebuildClaim bool                 `mapstructure:"prebuild_claim"`
+}
+
+type SimulatedWorkspace struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+}
+
+type SimulatedOwner struct {
+	ID           string          `mapstructure:"id"`
+	Name         string          `mapstructure:"name"`
+	FullName     string          `mapstructure:"full_name"`
+	Email        string          `mapstructure:"email"`
+	Groups       []string        `mapstructure:"groups"`
+	LoginType    string          `mapstructure:"login_type"`
+	SSHPublicKey string          `mapstructure:"ssh_public_key"`
+	RBACRoles    []OwnerRBACRole `mapstructure:"rbac_roles"`
+}
+
+func simulateSchema() *schema.Schema {
+	return &schema.Schema{
+		Type: schema.TypeList,
+		Description: "Feed deterministic values to all data sources for local template development, " +
+			"e.g. when running `terraform plan` outside of Coder. This block is ignored during " +
+			"workspace builds.",
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"workspace": {
+					Type:        schema.TypeList,
+					Description: "The simulated workspace.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"id": {
+								Type:         schema.TypeString,
+								Description:  "The UUID of the workspace. Defaults to a stable UUID derived from `name`.",
+								Optional:     true,
+								ValidateFunc: validation.IsUUID,
+							},
+							"name": {
+								Type:        schema.TypeString,
+								Description: "The name of the workspace.",
+								Optional:    true,
+							},
+						},
+					},
+				},
+				"owner": {
+					Type:        schema.TypeList,
+					Description: "The simulated workspace owner.",
+					Optional:    true,
+					MaxItems:    1,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"id": {
+								Type:         schema.TypeString,
+								Description:  "The UUID of the owner. Defaults to a stable UUID derived from `name`.",
+								Optional:     true,
+								ValidateFunc: validation.IsUUID,
+							},
+							"name": {
+								Type:        schema.TypeString,
+								Description: "The username of the owner.",
+								Optional:    true,
+							},
+							"full_name": {
+								Type:        schema.TypeString,
+								Description: "The full name of the owner.",
+								Optional:    true,
+							},
+							"email": {
+								Type:        schema.TypeString,
+								Description: "The email address of the owner.",
+								Optional:    true,
+							},
+							"groups": {
+								Type:        schema.TypeList,
+								Description: "The groups of which the owner is a member.",
+								Optional:    true,
+								Elem:        &schema.Schema{Type: schema.TypeString},
+							},
+							"login_type": {
+								Type:        schema.TypeString,
+								Description: "The type of login the owner has.",
+								Optional:    true,
+							},
+							"ssh_public_key": {
+								Type:        schema.TypeString,
+								Description: "The owner's SSH public key.",
+								Optional:    true,
+							},
+							"rbac_roles": {
+								Type:        schema.TypeList,
+								Description: "The RBAC roles the owner is assigned.",
+								Optional:    true,
+								Elem: &schema.Resource{
+									Schema: map[string]*schema.Schema{
+										"name": {
+											Type:        schema.TypeString,
+											Description: "The name of the RBAC role.",
+											Required:    true,
+										},
+										"org_id": {
+											Type:        schema.TypeString,
+											Description: "The organization ID associated with the RBAC role.",
+											Optional:    true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				"parameters": {
+					Type:        schema.TypeMap,
+					Description: "Values for `coder_parameter` data sources, keyed by parameter name.",
+					Optional:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+				"secrets": {
+					Type: schema.TypeMap,
+					Description: "Values for `coder_secret` data sources, keyed by `env` name or `file` path. " +
+						"Keys starting with `/` or `~/` are treated as file paths.",
+					Optional:  true,
+					Sensitive: true,
+					Elem:      &schema.Schema{Type: schema.TypeString},
+				},
+				"external_auth": {
+					Type:        schema.TypeMap,
+					Description: "Access tokens for `coder_external_auth` data sources, keyed by external auth provider ID.",
+					Optional:    true,
+					Sensitive:   true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+				"transition": {
+					Type:         schema.TypeString,
+					Description:  "The workspace transition. Must be one of: `\"start\"`, `\"stop\"`, `\"delete\"`.",
+					Optional:     true,
+					Default:      "start",
+					ValidateFunc: validation.StringInSlice([]string{"start", "stop", "delete"}, false),
+				},
+				"prebuild": {
+					Type:          schema.TypeBool,
+					Description:   "Simulate an unclaimed prebuilt workspace.",
+					Optional:      true,
+					ConflictsWith: []string{"simulate.0.prebuild_claim"},
+				},
+				"prebuild_claim": {
+					Type:          schema.TypeBool,
+					Description:   "Simulate the first build after a prebuilt workspace has been claimed.",
+					Optional:      true,
+					ConflictsWith: []string{"simulate.0.prebuild"},
+				},
+			},
+		},
+	}
+}
+
+// Simulate overlays the simulated values onto b.
+func (b *BuildContext) Simulate(sim Simulation) {
+	b.Transition = sim.Transition
+	b.IsPrebuild = sim.Prebuild
+	b.IsPrebuildClaim = sim.PrebuildClaim
+
+	if len(sim.Workspace) > 0 {
+		ws := sim.Workspace[0]
+		overlay(&b.Workspace.Name, ws.Name)
+		overlay(&b.Workspace.ID, ws.ID)
+	}
+	if b.Workspace.ID == "" {
+		b.Workspace.ID = simulatedID("workspace", b.Workspace.Name)
+	}
+
+	if len(sim.Owner) > 0 {
+		owner := sim.Owner[0]
+		overlay(&b.Owner.Name, owner.Name)
+		overlay(&b.Owner.ID, owner.ID)
+		overlay(&b.Owner.FullName, owner.FullName)
+		overlay(&b.Owner.Email, owner.Email)
+		overlay(&b.Owner.LoginType, owner.LoginType)
+		overlay(&b.Owner.SSHPublicKey, owner.SSHPublicKey)
+		if owner.Groups != nil {
+			b.Owner.Groups = owner.Groups
+		}
+		if owner.RBACRoles != nil {
+			b.Owner.RBACRoles = owner.RBACRoles
+		}
+	}
+	if b.Owner.ID == "" {
+		b.Owner.ID = simulatedID("owner", b.Owner.Name)
+	}
+
+	for name, value := range sim.Parameters {
+		b.SetParameter(name, value)
+	}
+	for key, value := range sim.Secrets {
+		if strings.HasPrefix(key, "/") || strings.HasPrefix(key, "~/") {
+			b.SetSecretFile(key, value)
+		} else {
+			b.SetSecretEnv(key, value)
+		}
+	}
+	for id, token := range sim.ExternalAuth {
+		b.SetExternalAuthAccessToken(id, token)
+	}
+}
+
+func simulatedID(kind, name string) string {
+	if name == "" {
+		name = "default"
+	}
+	return uuid.NewSHA1(simulationNamespace, []byte(kind+"/"+name)).String()
+}
@@ -0,0 +1,146 @@
+package provider_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+const simulateConfig = `
+provider "coder" {
+	simulate {
+		workspace {
+			name = "dev"
+		}
+		owner {
+			name   = "alice"
+			email  = "alice@example.com"
+			groups = ["admins"]
+			rbac_roles {
+				name   = "owner"
+				org_id = "00000000-0000-0000-0000-000000000000"
+			}
+		}
+		parameters = {
+			region = "eu-west-1"
+		}
+		secrets = {
+			GITHUB_TOKEN    = "gh-token"
+			"~/.ssh/id_rsa" = "private-key"
+		}
+		external_auth = {
+			github = "oauth-token"
+		}
+		transition     = "stop"
+		prebuild_claim = true
+	}
+}
+data "coder_workspace" "me" {}
+data "coder_workspace_owner" "me" {}
+data "coder_parameter" "region" {
+	name    = "region"
+	default = "us-east-1"
+}
+data "coder_secret" "github" {
+	env          = "GITHUB_TOKEN"
+	help_message = "Add a GitHub token"
+}
+data "coder_secret" "ssh" {
+	file         = "~/.ssh/id_rsa"
+	help_message = "Add an SSH key"
+}
+data "coder_external_auth" "github" {
+	id = "github"
+}
+`
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var workspaceID, ownerID string
+		check := func(state *terraform.State) error {
+			require.Len(t, state.Modules, 1)
+			resources := state.Modules[0].Resources
+			attr := func(name, key string) string {
+				res := resources[name]
+				require.NotNil(t, res, name)
+				return res.Primary.Attributes[key]
+			}
+
+			assert.Equal(t, "dev", attr("data.coder_workspace.me", "name"))
+			assert.Equal(t, "stop", attr("data.coder_workspace.me", "transition"))
+			assert.Equal(t, "0", attr("data.coder_workspace.me", "start_count"))
+			assert.Equal(t, "true", attr("data.coder_workspace.me", "is_prebuild_claim"))
+			assert.Equal(t, "alice", attr("data.coder_workspace_owner.me", "name"))
+			assert.Equal(t, "alice@example.com", attr("data.coder_workspace_owner.me", "email"))
+			assert.Equal(t, "admins", attr("data.coder_workspace_owner.me", "groups.0"))
+			assert.Equal(t, "owner", attr("data.coder_workspace_owner.me", "rbac_roles.0.name"))
+			assert.Equal(t, "eu-west-1", attr("data.coder_parameter.region", "value"))
+			assert.Equal(t, "gh-token", attr("data.coder_secret.github", "value"))
+			assert.Equal(t, "private-key", attr("data.coder_secret.ssh", "value"))
+			assert.Equal(t, "oauth-token", attr("data.coder_external_auth.github", "access_token"))
+
+			// IDs are derived from names, so they must be stable across runs.
+			if workspaceID == "" {
+				workspaceID = attr("data.coder_workspace.me", "id")
+				ownerID = attr("data.coder_workspace_owner.me", "id")
+			}
+			assert.Equal(t, workspaceID, attr("data.coder_workspace.me", "id"))
+			assert.Equal(t, ownerID, attr("data.coder_workspace_owner.me", "id"))
+			return nil
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(&provider.BuildContext{}),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{
+				{Config: simulateConfig, Check: check},
+				{Config: simulateConfig, Check: check},
+			},
+		})
+	})
+
+	t.Run("IgnoredDuringBuild", func(t *testing.T) {
+		t.Parallel()
+
+		bc := &provider.BuildContext{
+			BuildID:    "build-id",
+			Transition: "start",
+			Workspace:  provider.WorkspaceBuildContext{Name: "real"},
+			Template: provider.TemplateBuildContext{
+				ID:      "template-id",
+				Name:    "template",
+				Version: "v1",
+			},
+			Owner: provider.OwnerBuildContext{Name: "bob"},
+		}
+		bc.SetSecretEnv("GITHUB_TOKEN", "real-token")
+		bc.SetSecretFile("~/.ssh/id_rsa", "real-key")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: coderFactoryWithBuildContext(bc),
+			IsUnitTest:        true,
+			Steps: []resource.TestStep{{
+				Config: simulateConfig,
+				Check: func(state *terraform.State) error {
+					require.Len(t, state.Modules, 1)
+					resources := state.Modules[0].Resources
+					assert.Equal(t, "real", resources["data.coder_workspace.me"].Primary.Attributes["name"])
+					assert.Equal(t, "start", resources["data.coder_workspace.me"].Primary.Attributes["transition"])
+					assert.Equal(t, "bob", resources["data.coder_workspace_owner.me"].Primary.Attributes["name"])
+					assert.Equal(t, "us-east-1", resources["data.coder_parameter.region"].Primary.Attributes["value"])
+					assert.Equal(t, "real-token", resources["data.coder_secret.github"].Primary.Attributes["value"])
+					return nil
+				},
+			}},
+		})
+	})
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Every SDKv2 resource starts at SchemaVersion 1. When an attribute is
+// renamed, changes type or changes meaning, bump the resource's SchemaVersion
+// and pass a stateUpgrade from the previous version to withStateUpgrades.
+// Then record the previous state shape as a fixture in
+// testdata/state/<resource>/, together with the state it is expected to
+// upgrade to; TestStateUpgrade runs every fixture.
+//
+// Attributes which are only removed do not need an upgrade, since the SDK
+// drops attributes that are no longer in the schema.
+//
+// Only resources whose state shape has changed have upgrades: coder_agent,
+// for login_before_ready, api_key_scope, the display_apps set hash and the
+// lifecycle defaults; coder_app, for the healthcheck set hash; and
+// coder_ai_task, for app_id, which is missing from state written before it
+// was added even when the deprecated sidebar_app is set. The other resources
+// stay at SchemaVersion 1 until they need an upgrade.
+//
+// coder_agent.dir is deprecated, but it has kept its type and meaning, so
+// its state needs no upgrade. Removing it will not need one either.
+
+// stateUpgrade upgrades the raw JSON state of a resource by one version.
+type stateUpgrade struct {
+	// removed lists the attributes that were removed from the schema by the
+	// upgrade, along with their type. It is only used to decode state stored
+	// in the legacy flatmap format.
+	removed map[string]cty.Type
+	upgrade schema.StateUpgradeFunc
+}
+
+// noopStateUpgrade upgrades from version 0 to 1. Version 0 state has the
+// same shape as version 1, but the SDK only runs the upgrades of a resource
+// if there is one for every version since the state was written.
+var noopStateUpgrade = stateUpgrade{
+	upgrade: func(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+		return rawState, nil
+	},
+}
+
+// withStateUpgrades sets the StateUpgraders of resource to upgrades, which
+// must start at version 0 and end at the version before its SchemaVersion.
+func withStateUpgrades(resource *schema.Resource, upgrades ...stateUpgrade) {
+	resource.StateUpgraders = make([]schema.StateUpgrader, 0, len(upgrades))
+	// Walk backwards from the current schema, adding back the attributes
+	// removed by each upgrade to get the type of the previous version.
+	attrTypes := resource.CoreConfigSchema().ImpliedType().AttributeTypes()
+	for version := len(upgrades) - 1; version >= 0; version-- {
+		priorTypes := make(map[string]cty.Type, len(attrTypes))
+		for name, attrType := range attrTypes {
+			priorTypes[name] = attrType
+		}
+		for name, attrType := range upgrades[version].removed {
+			priorTypes[name] = attrType
+		}
+		attrTypes = priorTypes
+		resource.StateUpgraders = append([]schema.StateUpgrader{{
+			Version: version,
+			Type:    cty.Object(priorTypes),
+			Upgrade: upgrades[version].upgrade,
+		}}, resource.StateUpgraders...)
+	}
+}
+
+// aiTaskStateUpgradeV1 copies the ID of the deprecated sidebar_app into
+// app_id, for state written before app_id existed.
+var aiTaskStateUpgradeV1 = stateUpgrade{
+	upgrade: func(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+		if appID, _ := rawState["app_id"].(string); appID != "" {
+			return rawState, nil
+		}
+		sidebarApps, _ := rawState["sidebar_app"].([]interface{})
+		if len(sidebarApps) == 0 {
+			return rawState, nil
+		}
+		sidebarApp, _ := sidebarApps[0].(map[string]interface{})
+		if id, _ := sidebarApp["id"].(string); id != "" {
+			rawState["app_id"] = id
+		}
+		return rawState, nil
+	},
+}
+
+// agentStateUpgradeV1 replaces the removed login_before_ready attribute with
+// the equivalent startup_script_behavior. It also sets api_key_scope, which
+// forces a new agent, to its default for state written before it existed.
+var agentStateUpgradeV1 = stateUpgrade{
+	removed: map[string]cty.Type{
+		"login_before_ready": cty.Bool,
+	},
+	upgrade: func(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+		if scope, _ := rawState["api_key_scope"].(string); scope == "" {
+			rawState["api_key_scope"] = "all"
+		}
+		loginBeforeReady, ok := rawState["login_before_ready"].(bool)
+		delete(rawState, "login_before_ready")
+		if !ok {
+			return rawState, nil
+		}
+		if behavior, _ := rawState["startup_script_behavior"].(string); behavior != "" {
+			return rawState, nil
+		}
+		if loginBeforeReady {
+			rawState["startup_script_behavior"] = "non-blocking"
+		} else {
+			rawState["startup_script_behavior"] = "blocking"
+		}
+		return rawState, nil
+	},
+}
+
+// agentStateUpgradeV2 sets the apps and order of display_apps, which are part
+// of its set hash, to their defaults for state written before they existed.
+// It also sets the lifecycle settings to their defaults, so that existing
+// agents do not plan a change to add them.
+var agentStateUpgradeV2 = stateUpgrade{
+	upgrade: func(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+		if rawState["startup_timeout"] == nil {
+			rawState["startup_timeout"] = 0
+		}
+		if behavior, _ := rawState["on_startup_failure"].(string); behavior == "" {
+			rawState["on_startup_failure"] = "continue"
+		}
+		if rawState["shutdown_grace_period"] == nil {
+			rawState["shutdown_grace_period"] = 300
+		}
+		apps, _ := rawState["display_apps"].([]interface{})
+		for _, app := range apps {
+			app, ok := app.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if app["apps"] == nil {
+				app["apps"] = []interface{}{}
+			}
+			if app["order"] == nil {
+				app["order"] = []interface{}{}
+			}
+		}
+		return rawState, nil
+	},
+}
+
+// appStateUpgradeV1 sets the type and success_threshold of the healthcheck,
+// which are part of its set hash, to their defaults for state written before
+// they existed. Otherwise every app with a healthcheck would plan a change.
+var appStateUpgradeV1 = stateUpgrade{
+	upgrade: func(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+		healthchecks, _ := rawState["healthcheck"].([]interface{})
+		for _, healthcheck := range healthchecks {
+			healthcheck, ok := healthcheck.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if typ, _ := healthcheck["type"].(string); typ == "" {
+				healthcheck["type"] = "http"
+			}
+			if healthcheck["success_threshold"] == nil {
+				healthcheck["success_threshold"] = 1
+			}
+		}
+		return rawState, nil
+	},
+}
@@ -0,0 +1,100 @@
+package provider_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	ctymsgpack "github.com/hashicorp/go-cty/cty/msgpack"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/terraform-provider-coder/v2/provider"
+)
+
+// stateFixture is a state recorded at a previous schema version, and the
+// state it is expected to upgrade to. Null attributes and empty blocks may be
+// omitted from the upgraded state, including those of nested blocks.
+type stateFixture struct {
+	Version  int64           `json:"version"`
+	State    json.RawMessage `json:"state"`
+	Upgraded json.RawMessage `json:"upgraded"`
+}
+
+func TestStateUpgrade(t *testing.T) {
+	t.Parallel()
+
+	fixtures, err := filepath.Glob("testdata/state/*/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, path := range fixtures {
+		typeName := filepath.Base(filepath.Dir(path))
+		t.Run(typeName+"/"+strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			t.Parallel()
+
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var fixture stateFixture
+			require.NoError(t, json.Unmarshal(raw, &fixture))
+
+			tfProvider := provider.New()
+			res, ok := tfProvider.ResourcesMap[typeName]
+			require.True(t, ok, "unknown resource %q", typeName)
+			require.Less(t, fixture.Version, int64(res.SchemaVersion), "fixture is not from a previous version")
+
+			resp, err := schema.NewGRPCProviderServer(tfProvider).UpgradeResourceState(context.Background(), &tfprotov5.UpgradeResourceStateRequest{
+				TypeName: typeName,
+				Version:  fixture.Version,
+				RawState: &tfprotov5.RawState{JSON: fixture.State},
+			})
+			require.NoError(t, err)
+			for _, d := range resp.Diagnostics {
+				require.NotEqual(t, tfprotov5.DiagnosticSeverityError, d.Severity, "%s: %s", d.Summary, d.Detail)
+			}
+
+			ty := res.CoreConfigSchema().ImpliedType()
+			upgraded, err := ctymsgpack.Unmarshal(resp.UpgradedState.MsgPack, ty)
+			require.NoError(t, err)
+			actualJSON, err := ctyjson.Marshal(upgraded, ty)
+			require.NoError(t, err)
+			var expected, actual map[string]interface{}
+			require.NoError(t, json.Unmarshal(fixture.Upgraded, &expected))
+			require.NoError(t, json.Unmarshal(actualJSON, &actual))
+			// Attributes added to the schema since the fixture was recorded
+			// are null, and blocks are empty, so fixtures only list
+			// attributes that are not.
+			dropNewAttributes(expected, actual)
+			require.Equal(t, expected, actual)
+		})
+	}
+}
+
+// dropNewAttributes deletes the null attributes and empty blocks of actual
+// which are not in expected, including those of nested blocks.
+func dropNewAttributes(expected, actual interface{}) {
+	switch actual := actual.(type) {
+	case map[string]interface{}:
+		expected, _ := expected.(map[string]interface{})
+		for name, value := range actual {
+			expectedValue, ok := expected[name]
+			if list, isList := value.([]interface{}); !ok && (value == nil || isList && len(list) == 0) {
+				delete(actual, name)
+				continue
+			}
+			dropNewAttributes(expectedValue, value)
+		}
+	case []interface{}:
+		expected, _ := expected.([]interface{})
+		for i, value := range actual {
+			if i < len(expected) {
+				dropNewAttributes(expected[i], value)
+			}
+		}
+	}
+}
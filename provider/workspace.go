@@ -4,13 +4,10 @@ import (
 	"context"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-
-	"github.com/coder/terraform-provider-coder/v2/provider/helpers"
 )
 
 func workspaceDataSource() *schema.Resource {
@@ -19,7 +16,16 @@ func workspaceDataSource() *schema.Resource {
 
 		Description: "Use this data source to get information for the active workspace build.",
 		ReadContext: func(c context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
-			transition := helpers.OptionalEnvOrDefault("CODER_WORKSPACE_TRANSITION", "start") // Default to start!
+			config, valid := i.(config)
+			if !valid {
+				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
+			}
+			bc := config.BuildContext
+
+			transition := bc.Transition
+			if transition == "" {
+				transition = "start" // Default to start!
+			}
 			_ = rd.Set("transition", transition)
 
 			count := 0
@@ -28,7 +34,7 @@ func workspaceDataSource() *schema.Resource {
 			}
 			_ = rd.Set("start_count", count)
 
-			if isPrebuiltWorkspace() {
+			if bc.IsPrebuild {
 				_ = rd.Set("prebuild_count", 1)
 				_ = rd.Set("is_prebuild", true)
 
@@ -38,7 +44,7 @@ func workspaceDataSource() *schema.Resource {
 				_ = rd.Set("prebuild_count", 0)
 				_ = rd.Set("is_prebuild", false)
 			}
-			if isPrebuiltWorkspaceClaim() {
+			if bc.IsPrebuildClaim {
 				// Indicate that a prebuild claim has taken place.
 				_ = rd.Set("is_prebuild_claim", true)
 
@@ -49,34 +55,36 @@ func workspaceDataSource() *schema.Resource {
 				_ = rd.Set("is_prebuild_claim", false)
 			}
 
-			name := helpers.OptionalEnvOrDefault("CODER_WORKSPACE_NAME", "default")
+			name := bc.Workspace.Name
+			if name == "" {
+				name = "default"
+			}
 			rd.Set("name", name)
 
-			id := helpers.OptionalEnvOrDefault("CODER_WORKSPACE_ID", uuid.NewString())
+			id := bc.Workspace.ID
+			if id == "" {
+				id = uuid.NewString()
+			}
 			rd.SetId(id)
 
-			templateID, err := helpers.RequireEnv("CODER_WORKSPACE_TEMPLATE_ID")
+			templateID, err := bc.requireTemplateField("CODER_WORKSPACE_TEMPLATE_ID", bc.Template.ID)
 			if err != nil {
 				return diag.Errorf("template ID is missing: %s", err.Error())
 			}
 			_ = rd.Set("template_id", templateID)
 
-			templateName, err := helpers.RequireEnv("CODER_WORKSPACE_TEMPLATE_NAME")
+			templateName, err := bc.requireTemplateField("CODER_WORKSPACE_TEMPLATE_NAME", bc.Template.Name)
 			if err != nil {
 				return diag.Errorf("template name is missing: %s", err.Error())
 			}
 			_ = rd.Set("template_name", templateName)
 
-			templateVersion, err := helpers.RequireEnv("CODER_WORKSPACE_TEMPLATE_VERSION")
+			templateVersion, err := bc.requireTemplateField("CODER_WORKSPACE_TEMPLATE_VERSION", bc.Template.Version)
 			if err != nil {
 				return diag.Errorf("template version is missing: %s", err.Error())
 			}
 			_ = rd.Set("template_version", templateVersion)
 
-			config, valid := i.(config)
-			if !valid {
-				return diag.Errorf("config was unexpected type %q", reflect.TypeOf(i).String())
-			}
 			rd.Set("access_url", config.URL.String())
 
 			rawPort := config.URL.Port()
@@ -159,16 +167,6 @@ func workspaceDataSource() *schema.Resource {
 	}
 }
 
-// isPrebuiltWorkspace returns true if the workspace is an unclaimed prebuilt workspace.
-func isPrebuiltWorkspace() bool {
-	return strings.EqualFold(helpers.OptionalEnv(IsPrebuildEnvironmentVariable()), "true")
-}
-
-// isPrebuiltWorkspaceClaim returns true if the workspace is a prebuilt workspace which has just been claimed.
-func isPrebuiltWorkspaceClaim() bool {
-	return strings.EqualFold(helpers.OptionalEnv(IsPrebuildClaimEnvironmentVariable()), "true")
-}
-
 // IsPrebuildEnvironmentVariable returns the name of the environment variable that
 // indicates whether the workspace is an unclaimed prebuilt workspace.
 //
@@ -2,9 +2,6 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
-	"os"
-	"strings"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -15,55 +12,52 @@ func workspaceOwnerDataSource() *schema.Resource {
 	return &schema.Resource{
 		Description: "Use this data source to fetch information about the workspace owner.",
 		ReadContext: func(ctx context.Context, rd *schema.ResourceData, i interface{}) diag.Diagnostics {
-			diags := diag.Diagnostics{}
+			bc, diags := buildContextFromMeta(i)
+			if diags.HasError() {
+				return diags
+			}
+			owner := bc.Owner
 
-			if idStr := os.Getenv("CODER_WORKSPACE_OWNER_ID"); idStr != "" {
-				rd.SetId(idStr)
+			if owner.ID != "" {
+				rd.SetId(owner.ID)
 			} else {
 				rd.SetId(uuid.NewString())
 			}
 
-			if username := os.Getenv("CODER_WORKSPACE_OWNER"); username != "" {
-				_ = rd.Set("name", username)
+			if owner.Name != "" {
+				_ = rd.Set("name", owner.Name)
 			} else {
 				_ = rd.Set("name", "default")
 			}
 
-			if fullname := os.Getenv("CODER_WORKSPACE_OWNER_NAME"); fullname != "" {
-				_ = rd.Set("full_name", fullname)
+			if owner.FullName != "" {
+				_ = rd.Set("full_name", owner.FullName)
 			} else { // compat: field can be blank, fill in default
 				_ = rd.Set("full_name", "default")
 			}
 
-			if email := os.Getenv("CODER_WORKSPACE_OWNER_EMAIL"); email != "" {
-				_ = rd.Set("email", email)
+			if owner.Email != "" {
+				_ = rd.Set("email", owner.Email)
 			} else {
 				_ = rd.Set("email", "default@example.com")
 			}
 
-			_ = rd.Set("ssh_public_key", os.Getenv("CODER_WORKSPACE_OWNER_SSH_PUBLIC_KEY"))
-			_ = rd.Set("ssh_private_key", os.Getenv("CODER_WORKSPACE_OWNER_SSH_PRIVATE_KEY"))
-
-			var groups []string
-			if groupsRaw, ok := os.LookupEnv("CODER_WORKSPACE_OWNER_GROUPS"); ok {
-				if err := json.NewDecoder(strings.NewReader(groupsRaw)).Decode(&groups); err != nil {
-					return diag.Errorf("invalid user groups: %s", err.Error())
-				}
-			}
-			_ = rd.Set("groups", groups)
-
-			_ = rd.Set("session_token", os.Getenv("CODER_WORKSPACE_OWNER_SESSION_TOKEN"))
-			_ = rd.Set("oidc_access_token", os.Getenv("CODER_WORKSPACE_OWNER_OIDC_ACCESS_TOKEN"))
+			_ = rd.Set("ssh_public_key", owner.SSHPublicKey)
+			_ = rd.Set("ssh_private_key", owner.SSHPrivateKey)
+			_ = rd.Set("groups", owner.Groups)
+			_ = rd.Set("session_token", owner.SessionToken)
+			_ = rd.Set("oidc_access_token", owner.OIDCAccessToken)
 
-			if loginType := os.Getenv("CODER_WORKSPACE_OWNER_LOGIN_TYPE"); loginType != "" {
-				_ = rd.Set("login_type", loginType)
+			if owner.LoginType != "" {
+				_ = rd.Set("login_type", owner.LoginType)
 			}
 
-			var rbacRoles []map[string]string
-			if rolesRaw, ok := os.LookupEnv("CODER_WORKSPACE_OWNER_RBAC_ROLES"); ok {
-				if err := json.NewDecoder(strings.NewReader(rolesRaw)).Decode(&rbacRoles); err != nil {
-					return diag.Errorf("invalid user rbac roles: %s", err.Error())
-				}
+			rbacRoles := make([]map[string]string, 0, len(owner.RBACRoles))
+			for _, role := range owner.RBACRoles {
+				rbacRoles = append(rbacRoles, map[string]string{
+					"name":   role.Name,
+					"org_id": role.OrgID,
+				})
 			}
 			_ = rd.Set("rbac_roles", rbacRoles)
 